@@ -0,0 +1,133 @@
+package main
+
+import (
+	"errors"
+	"github.com/air-bnb/internal/data"
+	"github.com/air-bnb/internal/validator"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+)
+
+func (app *application) getAmenitiesHandler(w http.ResponseWriter, r *http.Request) {
+	amenities, err := app.models.Amenities.GetAll()
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"amenities": amenities}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) createAmenityHandler(w http.ResponseWriter, r *http.Request) {
+	var input data.Amenity
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	if data.ValidateAmenity(v, &input); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Amenities.Insert(&input)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateAmenity):
+			v.AddError("key", "an amenity with this key already exists")
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"amenity": input}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// updateAmenityHandler changes an amenity's label, icon or group. Its key is fixed, since listings
+// and searches refer to it.
+func (app *application) updateAmenityHandler(w http.ResponseWriter, r *http.Request) {
+	amenity, err := app.models.Amenities.Get(chi.URLParam(r, "key"))
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	var input struct {
+		Label *string `json:"label"`
+		Icon  *string `json:"icon"`
+		Group *string `json:"group"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if input.Label != nil {
+		amenity.Label = *input.Label
+	}
+	if input.Icon != nil {
+		amenity.Icon = *input.Icon
+	}
+	if input.Group != nil {
+		amenity.Group = *input.Group
+	}
+
+	v := validator.New()
+	if data.ValidateAmenity(v, amenity); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Amenities.Update(amenity)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"amenity": amenity}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// deleteAmenityHandler removes an amenity from the catalogue and from every listing that had it.
+func (app *application) deleteAmenityHandler(w http.ResponseWriter, r *http.Request) {
+	err := app.models.Amenities.Delete(chi.URLParam(r, "key"))
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "amenity successfully deleted"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
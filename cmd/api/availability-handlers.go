@@ -0,0 +1,43 @@
+package main
+
+import (
+	"github.com/air-bnb/internal/data"
+	"github.com/air-bnb/internal/validator"
+	"net/http"
+	"time"
+)
+
+func (app *application) getAvailabilityHandler(w http.ResponseWriter, r *http.Request) {
+	listing, ok := app.readableListing(w, r)
+	if !ok {
+		return
+	}
+
+	v := validator.New()
+	qs := r.URL.Query()
+
+	from := app.readDate(qs, "from", v)
+	if from.IsZero() && !qs.Has("from") {
+		from = time.Now().UTC().Truncate(24 * time.Hour)
+	}
+	to := app.readDate(qs, "to", v)
+	if to.IsZero() && !qs.Has("to") {
+		to = from.AddDate(0, 0, 90)
+	}
+
+	if data.ValidateCalendarRange(v, from, to); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	days, err := app.models.Availability.Get(listing.ID, from, to)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"availability": days}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"errors"
+	"github.com/air-bnb/internal/data"
+	"github.com/air-bnb/internal/payments"
+	"github.com/air-bnb/internal/validator"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// updateBookingHandler lets the guest propose new dates or a new guest count. The stay is re-quoted
+// and checked against the listing's rules and other bookings; on instant-book listings the change is
+// applied straight away, otherwise it waits for a host to approve it.
+func (app *application) updateBookingHandler(w http.ResponseWriter, r *http.Request) {
+	session := app.contextGetUser(r)
+
+	booking, ok := app.readableBooking(w, r)
+	if !ok {
+		return
+	}
+	if booking.GuestID != session.ID {
+		app.notPermittedResponse(w, r)
+		return
+	}
+
+	var input struct {
+		StartDate *time.Time `json:"startDate"`
+		EndDate   *time.Time `json:"endDate"`
+		Guests    *int64     `json:"guests"`
+		// PaymentMethod pays for a change that costs more than the booking.
+		PaymentMethod string `json:"paymentMethod"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	req := data.QuoteRequest{
+		ListingID: booking.ListingID,
+		CheckIn:   booking.CheckIn,
+		CheckOut:  booking.CheckOut,
+		Guests:    booking.Guests,
+	}
+	if input.StartDate != nil {
+		req.CheckIn = *input.StartDate
+	}
+	if input.EndDate != nil {
+		req.CheckOut = *input.EndDate
+	}
+	if input.Guests != nil {
+		req.Guests = *input.Guests
+	}
+
+	v := validator.New()
+	if data.ValidateQuoteRequest(v, req); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	quote, err := app.models.Quotes.Get(req)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrGuestCapacity):
+			v.AddError("guests", "must not exceed the listing's guest capacity")
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	change := data.NewBookingChange(booking, quote, session.ID)
+	change.PaymentMethod = input.PaymentMethod
+	if data.ValidateBookingChange(v, booking, change); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	// Bookings made before payments were introduced have nothing to charge the difference to.
+	if change.PriceDifference() > 0 {
+		_, err = app.models.Payments.GetForBooking(booking.ID)
+		switch {
+		case err == nil:
+			v.Check(change.PaymentMethod != "", "paymentMethod", "must be provided when the change costs more")
+		case !errors.Is(err, data.ErrRecordNotFound):
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+		if !v.Valid() {
+			app.failedValidationResponse(w, r, v.Errors)
+			return
+		}
+	}
+
+	// Stay rules only apply to new dates; changing the guest count of a stay that starts tomorrow
+	// must not fail on the listing's advance notice.
+	if input.StartDate != nil || input.EndDate != nil {
+		rules, err := app.models.StayRules.Get(booking.ListingID)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		blocks, err := app.models.Blocks.GetForListing(booking.ListingID, req.CheckIn, req.CheckOut)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		if data.ValidateStay(v, rules, blocks, req.CheckIn, req.CheckOut, time.Now()); !v.Valid() {
+			app.failedValidationResponse(w, r, v.Errors)
+			return
+		}
+	}
+
+	conflict, err := app.models.Bookings.FindConflict(booking.ListingID, req.CheckIn, req.CheckOut, booking.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if conflict != nil {
+		app.bookingConflictResponse(w, r, conflict)
+		return
+	}
+
+	err = app.models.Changes.Insert(change)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrPendingChange):
+			app.pendingChangeResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if !booking.Listing.InstantBook {
+		env := envelope{"change": change, "priceDifference": change.PriceDifference(), "quote": quote}
+		err = app.writeJSON(w, http.StatusAccepted, env, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	app.applyBookingChange(w, r, booking, change, quote.LineItems())
+}
+
+func (app *application) getBookingChangesHandler(w http.ResponseWriter, r *http.Request) {
+	booking, ok := app.readableBooking(w, r)
+	if !ok {
+		return
+	}
+
+	changes, err := app.models.Changes.GetForBooking(booking.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"changes": changes}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) approveBookingChangeHandler(w http.ResponseWriter, r *http.Request) {
+	booking, change, ok := app.hostBookingChange(w, r)
+	if !ok {
+		return
+	}
+
+	// The stay is priced again so that the booking gets a breakdown that adds up to the change's
+	// total. If the price has moved since the guest asked, the guest has to ask again.
+	quote, err := app.models.Quotes.Get(data.QuoteRequest{
+		ListingID: booking.ListingID,
+		CheckIn:   change.ToCheckIn,
+		CheckOut:  change.ToCheckOut,
+		Guests:    change.ToGuests,
+	})
+	if err != nil && !errors.Is(err, data.ErrGuestCapacity) {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if err != nil || quote.Total != change.ToTotal {
+		err = app.models.Changes.Decline(change)
+		if err != nil && !errors.Is(err, data.ErrEditConflict) {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+		app.changeRepricedResponse(w, r)
+		return
+	}
+
+	app.applyBookingChange(w, r, booking, change, quote.LineItems())
+}
+
+func (app *application) declineBookingChangeHandler(w http.ResponseWriter, r *http.Request) {
+	_, change, ok := app.hostBookingChange(w, r)
+	if !ok {
+		return
+	}
+
+	err := app.models.Changes.Decline(change)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"change": change}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// applyBookingChange applies a pending change with items as the booking's new breakdown, charging or
+// refunding the price difference, and writes the updated booking. If the new dates were taken since
+// the change was requested or the payment fails, the change is declined and the reason reported.
+func (app *application) applyBookingChange(w http.ResponseWriter, r *http.Request, booking *data.Booking, change *data.BookingChange, items []*data.LineItem) {
+	err := app.models.Changes.Apply(booking, change, items, func() error {
+		return app.settleBookingChange(r.Context(), booking, change)
+	})
+	if err != nil {
+		failed := errors.Is(err, data.ErrBookingConflict) || errors.Is(err, payments.ErrDeclined) ||
+			errors.Is(err, payments.ErrUnknownPayment)
+		if failed {
+			if declineErr := app.models.Changes.Decline(change); declineErr != nil {
+				app.logError(r, declineErr)
+			}
+		}
+
+		switch {
+		case errors.Is(err, data.ErrBookingConflict):
+			app.bookingConflictResponse(w, r, err)
+		case errors.Is(err, payments.ErrDeclined):
+			app.paymentDeclinedResponse(w, r)
+		case errors.Is(err, payments.ErrUnknownPayment):
+			app.unknownPaymentResponse(w, r)
+		case errors.Is(err, data.ErrDatesHeld):
+			app.datesHeldResponse(w, r)
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	env := envelope{"booking": booking, "change": change, "priceDifference": change.PriceDifference()}
+	err = app.writeJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// hostBookingChange loads the booking and change named in the URL and checks that the session user
+// hosts the booking's listing. On failure it has already written the error response.
+func (app *application) hostBookingChange(w http.ResponseWriter, r *http.Request) (*data.Booking, *data.BookingChange, bool) {
+	booking, ok := app.readableBooking(w, r)
+	if !ok {
+		return nil, nil, false
+	}
+
+	host, err := app.isListingHost(app.contextGetUser(r), &booking.Listing)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return nil, nil, false
+	}
+	if !host {
+		app.notPermittedResponse(w, r)
+		return nil, nil, false
+	}
+
+	changeID, err := strconv.ParseInt(chi.URLParam(r, "changeId"), 10, 64)
+	if err != nil || changeID < 1 {
+		app.notFoundResponse(w, r)
+		return nil, nil, false
+	}
+
+	change, err := app.models.Changes.Get(changeID, booking.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return nil, nil, false
+	}
+
+	return booking, change, true
+}
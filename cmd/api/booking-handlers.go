@@ -2,8 +2,8 @@ package main
 
 import (
 	"errors"
-	"fmt"
 	"github.com/air-bnb/internal/data"
+	"github.com/air-bnb/internal/payments"
 	"github.com/air-bnb/internal/validator"
 	"github.com/go-chi/chi/v5"
 	"net/http"
@@ -18,7 +18,16 @@ func (app *application) createBookingHandler(w http.ResponseWriter, r *http.Requ
 		ListingID int64     `json:"listingId"`
 		StartDate time.Time `json:"startDate"`
 		EndDate   time.Time `json:"endDate"`
-		Pricing   int64     `json:"pricing"`
+		Adults    int64     `json:"adults"`
+		Children  int64     `json:"children"`
+		Infants   int64     `json:"infants"`
+		Pets      int64     `json:"pets"`
+		// Guests is still accepted from older clients and counted as adults.
+		Guests int64 `json:"guests"`
+		// PaymentMethod is the card token the client obtained from the payment provider.
+		PaymentMethod string `json:"paymentMethod"`
+		// Pricing is still accepted from older clients but ignored; the total is quoted server-side.
+		Pricing int64 `json:"pricing"`
 	}
 
 	err := app.readJSON(w, r, &input)
@@ -27,84 +36,266 @@ func (app *application) createBookingHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	total := input.Pricing * int64(input.EndDate.Sub(input.StartDate).Hours()/24)
+	if input.Adults == 0 && input.Children == 0 {
+		input.Adults = max(input.Guests, 1)
+	}
 
-	booking := &data.Booking{
+	req := data.QuoteRequest{
 		ListingID: input.ListingID,
-		GuestID:   session.ID,
 		CheckIn:   input.StartDate,
 		CheckOut:  input.EndDate,
-		Price:     input.Pricing,
-		Total:     total,
+		Guests:    input.Adults + input.Children,
 	}
 
 	v := validator.New()
-	data.ValidateBooking(v, booking)
-	fmt.Println(v.Errors)
-	if !v.Valid() {
+	if data.ValidateQuoteRequest(v, req); !v.Valid() {
 		app.failedValidationResponse(w, r, v.Errors)
 		return
 	}
 
-	err = app.models.Bookings.Insert(booking)
+	quote, err := app.models.Quotes.Get(req)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			v.AddError("listingId", "listing does not exist")
+			app.failedValidationResponse(w, r, v.Errors)
+		case errors.Is(err, data.ErrGuestCapacity):
+			v.AddError("guests", "must not exceed the listing's guest capacity")
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	rules, err := app.models.StayRules.Get(input.ListingID)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusCreated, envelope{"booking": booking}, nil)
+	blocks, err := app.models.Blocks.GetForListing(input.ListingID, input.StartDate, input.EndDate)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
+		return
 	}
 
-}
+	if data.ValidateStay(v, rules, blocks, input.StartDate, input.EndDate, time.Now()); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
 
-func (app *application) getBookingHandler(w http.ResponseWriter, r *http.Request) {
-	params := chi.URLParam(r, "id")
-	id, err := strconv.ParseInt(params, 10, 64)
-	if err != nil || id < 1 {
-		app.notFoundResponse(w, r)
+	booking := &data.Booking{
+		ListingID: input.ListingID,
+		GuestID:   session.ID,
+		CheckIn:   input.StartDate,
+		CheckOut:  input.EndDate,
+		Guests:    req.Guests,
+		Adults:    input.Adults,
+		Children:  input.Children,
+		Infants:   input.Infants,
+		Pets:      input.Pets,
+		Price:     quote.BasePrice,
+		Total:     quote.Total,
+		LineItems: quote.LineItems(),
+	}
+
+	listing, err := app.models.Listings.Get(input.ListingID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if listing.Status != data.ListingPublished {
+		v.AddError("listingId", "listing is not open for bookings")
+		app.failedValidationResponse(w, r, v.Errors)
 		return
 	}
 
-	booking, err := app.models.Bookings.Get(id)
+	ok, err := app.canBookListing(session, listing)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if !ok {
+		app.notPermittedResponse(w, r)
+		return
+	}
+
+	// Instant-book listings skip the host's approval and the payment is captured straight away below.
+	// Otherwise the booking is a request that expires if the host doesn't answer in time.
+	now := time.Now()
+	if listing.InstantBook {
+		booking.Status = data.BookingConfirmed
+		booking.ConfirmedAt = &now
+	} else {
+		expiresAt := now.Add(time.Duration(listing.RequestExpiryHours) * time.Hour)
+		booking.ExpiresAt = &expiresAt
+	}
+
+	data.ValidateBooking(v, booking, listing)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Bookings.Insert(booking)
 	if err != nil {
 		switch {
-		case errors.Is(err, data.ErrRecordNotFound):
-			app.notFoundResponse(w, r)
+		case errors.Is(err, data.ErrBookingConflict):
+			app.bookingConflictResponse(w, r, err)
+		case errors.Is(err, data.ErrDatesHeld):
+			app.datesHeldResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	payment, err := app.authorizeBooking(r.Context(), booking, input.PaymentMethod)
+	if err == nil && listing.InstantBook {
+		err = app.settleBooking(r.Context(), booking)
+		if err != nil {
+			if voidErr := app.payments.Void(r.Context(), payment.Reference); voidErr != nil {
+				app.logError(r, voidErr)
+			}
+		} else {
+			payment, err = app.models.Payments.GetForBooking(booking.ID)
+		}
+	}
+	if err != nil {
+		// An unpaid booking must not keep holding the dates.
+		if deleteErr := app.models.Bookings.Delete(booking.ID, session.ID); deleteErr != nil {
+			app.logError(r, deleteErr)
+		}
+
+		switch {
+		case errors.Is(err, payments.ErrDeclined):
+			app.paymentDeclinedResponse(w, r)
 		default:
 			app.serverErrorResponse(w, r, err)
 		}
 		return
 	}
 
+	err = app.writeJSON(w, http.StatusCreated, envelope{"booking": booking, "quote": quote, "payment": payment}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+
+}
+
+func (app *application) getBookingHandler(w http.ResponseWriter, r *http.Request) {
+	booking, ok := app.readableBooking(w, r)
+	if !ok {
+		return
+	}
+
+	var err error
+	booking.LineItems, err = app.models.LineItems.GetForBooking(booking.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
 	err = app.writeJSON(w, http.StatusOK, envelope{"booking": booking}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
 }
 
-func (app *application) deleteBookingHandler(w http.ResponseWriter, r *http.Request) {
+func (app *application) acceptBookingHandler(w http.ResponseWriter, r *http.Request) {
+	app.transitionBooking(w, r, data.BookingConfirmed, func(user *data.User, booking *data.Booking) (bool, error) {
+		return app.isListingHost(user, &booking.Listing)
+	})
+}
+
+func (app *application) declineBookingHandler(w http.ResponseWriter, r *http.Request) {
+	app.transitionBooking(w, r, data.BookingDeclined, func(user *data.User, booking *data.Booking) (bool, error) {
+		return app.isListingHost(user, &booking.Listing)
+	})
+}
+
+func (app *application) cancelBookingHandler(w http.ResponseWriter, r *http.Request) {
+	app.transitionBooking(w, r, data.BookingCancelled, func(user *data.User, booking *data.Booking) (bool, error) {
+		return booking.GuestID == user.ID, nil
+	})
+}
+
+// setRefundAmount works out what a guest cancelling the booking gets back. A pending booking was
+// never charged, so the whole total is released; a confirmed one is refunded by the policy the
+// booking was made under.
+func setRefundAmount(booking *data.Booking, from string) {
+	if from == data.BookingPending {
+		booking.RefundAmount = booking.Total
+		return
+	}
+
+	booking.RefundAmount = booking.CancellationPolicy.Refund(booking.Total, booking.CheckIn, time.Now())
+}
+
+// transitionBooking moves the booking in the URL to status if allowed returns true for the session user.
+func (app *application) transitionBooking(w http.ResponseWriter, r *http.Request, status string, allowed func(*data.User, *data.Booking) (bool, error)) {
 	session := app.contextGetUser(r)
-	params := chi.URLParam(r, "id")
-	id, err := strconv.ParseInt(params, 10, 64)
-	if err != nil || id < 1 {
-		app.notFoundResponse(w, r)
+	booking, ok := app.readableBooking(w, r)
+	if !ok {
 		return
 	}
 
-	err = app.models.Bookings.Delete(id, session.ID)
+	ok, err := allowed(session, booking)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if !ok {
+		app.notPermittedResponse(w, r)
+		return
+	}
+
+	from, previous := booking.Status, *booking
+	err = booking.Transition(status)
 	if err != nil {
 		switch {
-		case errors.Is(err, data.ErrRecordNotFound):
-			app.notFoundResponse(w, r)
+		case errors.Is(err, data.ErrRequestExpired):
+			app.requestExpiredResponse(w, r)
+		default:
+			app.invalidTransitionResponse(w, r, from, status)
+		}
+		return
+	}
+
+	if status == data.BookingCancelled {
+		setRefundAmount(booking, from)
+	}
+
+	// The new status is claimed before any money moves, so of two racing requests only one settles
+	// the payment; the other gets an edit conflict.
+	err = app.models.Bookings.UpdateStatus(booking, from)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	// A failed settlement puts the booking back as it was, so that the request can be retried.
+	err = app.settleBooking(r.Context(), booking)
+	if err != nil {
+		if revertErr := app.models.Bookings.UpdateStatus(&previous, status); revertErr != nil {
+			app.logError(r, revertErr)
+		}
+		switch {
+		case errors.Is(err, payments.ErrUnknownPayment):
+			app.unknownPaymentResponse(w, r)
 		default:
 			app.serverErrorResponse(w, r, err)
 		}
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"message": "booking successfully deleted"}, nil)
+	err = app.writeJSON(w, http.StatusOK, envelope{"booking": booking}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
@@ -113,9 +304,25 @@ func (app *application) deleteBookingHandler(w http.ResponseWriter, r *http.Requ
 func (app *application) getUserBookingsHandler(w http.ResponseWriter, r *http.Request) {
 	session := app.contextGetUser(r)
 
-	bookings, err := app.models.Bookings.GetForUser(session.ID)
+	v := validator.New()
+	status := app.readString(r.URL.Query(), "status", "")
+	data.ValidateBookingStatusFilter(v, status)
+	filters, ok := app.readPaging(r.URL.Query(), "-created_at", []string{"id", "-id", "created_at", "-created_at",
+		"check_in", "-check_in"}, v)
+	if !ok {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	bookings, metadata, err := app.models.Bookings.GetForUser(session.ID, status, filters)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		switch {
+		case errors.Is(err, data.ErrInvalidCursor):
+			v.AddError("cursor", "Must be a cursor from a previous page")
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
 		return
 	}
 
@@ -129,7 +336,7 @@ func (app *application) getUserBookingsHandler(w http.ResponseWriter, r *http.Re
 		booking.Listing.Images = image
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"bookings": bookings}, nil)
+	err = app.writeJSON(w, http.StatusOK, envelope{"bookings": bookings, "metadata": metadata}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
@@ -143,7 +350,30 @@ func (app *application) getPropertyBookingsHandler(w http.ResponseWriter, r *htt
 		return
 	}
 
-	bookings, err := app.models.Bookings.GetForListing(id)
+	listing, err := app.models.Listings.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if !app.canReadListingBookings(app.contextGetUser(r), listing) {
+		app.notPermittedResponse(w, r)
+		return
+	}
+
+	v := validator.New()
+	status := app.readString(r.URL.Query(), "status", "")
+	if data.ValidateBookingStatusFilter(v, status); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	bookings, err := app.models.Bookings.GetForListing(id, status)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
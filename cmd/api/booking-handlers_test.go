@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/air-bnb/internal/data"
+	"github.com/air-bnb/internal/payments"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+func TestGetUserBookingsHandler_Paging(t *testing.T) {
+	owner := createTestUser(t, false)
+	guest := createTestUser(t, false)
+	listing := createTestListing(t, owner)
+	for i := 0; i < 3; i++ {
+		createTestBooking(t, guest, listing)
+	}
+
+	var body struct {
+		Bookings []struct {
+			ID int64 `json:"id"`
+		} `json:"bookings"`
+		Metadata struct {
+			Next string `json:"next"`
+		} `json:"metadata"`
+	}
+
+	w := serve(t, http.MethodGet, "/v1/bookings/user-bookings", &guest)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	require.Len(t, body.Bookings, 3)
+	require.Empty(t, body.Metadata.Next)
+
+	w = serve(t, http.MethodGet, "/v1/bookings/user-bookings?page_size=2&sort=id", &guest)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	require.Len(t, body.Bookings, 2)
+	first, next := body.Bookings[0].ID, body.Metadata.Next
+
+	w = serve(t, http.MethodGet, "/v1/bookings/user-bookings?page_size=2&sort=id&cursor="+next, &guest)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	require.Len(t, body.Bookings, 1)
+	require.Greater(t, body.Bookings[0].ID, first)
+
+	// A cursor only continues the sort it was made for.
+	w = serve(t, http.MethodGet, "/v1/bookings/user-bookings?page_size=2&sort=-id&cursor="+next, &guest)
+	require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	w = serve(t, http.MethodGet, "/v1/bookings/user-bookings?cursor=garbage", &guest)
+	require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+}
+
+func TestAcceptBookingHandler_Settlement(t *testing.T) {
+	owner := createTestUser(t, false)
+	guest := createTestUser(t, false)
+	listing := createTestListing(t, owner)
+
+	// The provider has never seen this payment, so capturing it fails.
+	unknown := createTestBooking(t, guest, listing)
+	require.NoError(t, testApp.models.Payments.Insert(&data.Payment{
+		BookingID: unknown.ID,
+		Provider:  payments.FakeProviderName,
+		Reference: "fake_unknown",
+		Status:    data.PaymentAuthorized,
+		Amount:    unknown.Total,
+	}))
+
+	path := fmt.Sprintf("/v1/bookings/%d/accept", unknown.ID)
+	require.Equal(t, http.StatusConflict, serve(t, http.MethodPatch, path, &owner).Code)
+	booking, err := testApp.models.Bookings.Get(unknown.ID)
+	require.NoError(t, err)
+	require.Equal(t, data.BookingPending, booking.Status)
+
+	// Only the first of two accepts captures the payment.
+	paid := createTestBooking(t, guest, listing)
+	_, err = testApp.authorizeBooking(context.Background(), paid, "tok_visa")
+	require.NoError(t, err)
+
+	path = fmt.Sprintf("/v1/bookings/%d/accept", paid.ID)
+	require.Equal(t, http.StatusOK, serve(t, http.MethodPatch, path, &owner).Code)
+	require.Equal(t, http.StatusConflict, serve(t, http.MethodPatch, path, &owner).Code)
+
+	payment, err := testApp.models.Payments.GetForBooking(paid.ID)
+	require.NoError(t, err)
+	require.Equal(t, data.PaymentCaptured, payment.Status)
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/air-bnb/internal/data"
+	"github.com/air-bnb/internal/ical"
+	"github.com/air-bnb/internal/validator"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// exportCalendarHandler serves the listing's bookings and blocked dates as an iCalendar feed for
+// other platforms to import. Calendar clients can't send our auth headers, so the feed is protected
+// by the secret token in its URL instead; a missing or wrong token looks like a missing listing.
+func (app *application) exportCalendarHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "listingId"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	ok, err := app.models.Calendars.Authenticate(id, token)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if !ok {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	listing, err := app.models.Listings.Get(id)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	now := time.Now()
+	entries, err := app.models.Calendars.GetEntries(id, now.Add(-data.CalendarFeedHistory))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	cal := &ical.Calendar{
+		ProdID: "-//air-bnb//listing calendar//EN",
+		Name:   listing.Title,
+		Events: make([]ical.Event, 0, len(entries)),
+	}
+	for _, entry := range entries {
+		cal.Events = append(cal.Events, calendarEvent(entry, now))
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="listing-%d.ics"`, id))
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+
+	err = cal.Write(w)
+	if err != nil {
+		app.logError(r, err)
+	}
+}
+
+func calendarEvent(entry *data.CalendarFeedEntry, stamp time.Time) ical.Event {
+	event := ical.Event{
+		UID:   fmt.Sprintf("%s-%d@air-bnb", entry.Kind, entry.ID),
+		Stamp: stamp,
+		Start: entry.StartDate,
+		End:   entry.EndDate,
+	}
+
+	switch entry.Kind {
+	case data.FeedEntryBooking:
+		event.Summary = "Reserved"
+		event.Description = fmt.Sprintf("Booking #%d for %d guests", entry.ID, entry.Guests)
+		event.Status = ical.StatusConfirmed
+		if entry.Status == data.BookingPending {
+			event.Status = ical.StatusTentative
+		}
+	default:
+		event.Summary = "Not available"
+		event.Description = entry.Note
+		event.Status = ical.StatusConfirmed
+	}
+
+	return event
+}
+
+// rotateCalendarTokenHandler issues a new feed URL for the listing and disables the old one. The
+// token is not stored, so this is also how an owner gets the URL again.
+func (app *application) rotateCalendarTokenHandler(w http.ResponseWriter, r *http.Request) {
+	listing, ok := app.ownedListing(w, r)
+	if !ok {
+		return
+	}
+
+	token, err := app.models.Calendars.Rotate(listing.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	url := fmt.Sprintf("/v1/listings/%d/calendar.ics?token=%s", listing.ID, token)
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"calendarUrl": url}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) getCalendarImportsHandler(w http.ResponseWriter, r *http.Request) {
+	listing, ok := app.ownedListing(w, r)
+	if !ok {
+		return
+	}
+
+	imports, err := app.models.Imports.GetForListing(listing.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"calendarImports": imports}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// createCalendarImportHandler registers an external calendar and syncs it straight away. A failed
+// first sync still creates the import; its lastError tells the host what went wrong.
+func (app *application) createCalendarImportHandler(w http.ResponseWriter, r *http.Request) {
+	listing, ok := app.ownedListing(w, r)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	imp := &data.CalendarImport{ListingID: listing.ID, Name: input.Name, URL: input.URL}
+
+	v := validator.New()
+	if data.ValidateCalendarImport(v, imp); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Imports.Insert(imp)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateImport):
+			v.AddError("url", "this calendar is already imported")
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	app.syncNow(r, imp)
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"calendarImport": imp}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) syncCalendarImportHandler(w http.ResponseWriter, r *http.Request) {
+	imp, ok := app.ownedCalendarImport(w, r)
+	if !ok {
+		return
+	}
+
+	app.syncNow(r, imp)
+
+	err := app.writeJSON(w, http.StatusOK, envelope{"calendarImport": imp}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteCalendarImportHandler(w http.ResponseWriter, r *http.Request) {
+	imp, ok := app.ownedCalendarImport(w, r)
+	if !ok {
+		return
+	}
+
+	err := app.models.Imports.Delete(imp.ID, imp.ListingID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "calendar import deleted"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// syncNow syncs imp while the host waits. Problems with the feed end up in imp.LastError, so only
+// failures of our own are logged.
+func (app *application) syncNow(r *http.Request, imp *data.CalendarImport) {
+	err := app.syncCalendarImport(r.Context(), imp)
+	if err != nil && imp.LastError != err.Error() {
+		app.logError(r, err)
+	}
+}
+
+func (app *application) ownedCalendarImport(w http.ResponseWriter, r *http.Request) (*data.CalendarImport, bool) {
+	listing, ok := app.ownedListing(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "importId"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return nil, false
+	}
+
+	imp, err := app.models.Imports.Get(id, listing.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return nil, false
+	}
+
+	return imp, true
+}
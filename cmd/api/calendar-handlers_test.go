@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCalendarFeed(t *testing.T) {
+	owner := createTestUser(t, false)
+	guest := createTestUser(t, false)
+
+	listing := createTestListing(t, owner)
+	booking := createTestBooking(t, guest, listing)
+	rotatePath := fmt.Sprintf("/v1/listings/%d/calendar-token", listing.ID)
+
+	require.Equal(t, http.StatusForbidden, serve(t, http.MethodPost, rotatePath, &guest).Code)
+	require.Equal(t, http.StatusUnauthorized, serve(t, http.MethodPost, rotatePath, nil).Code)
+
+	rotate := func() string {
+		w := serve(t, http.MethodPost, rotatePath, &owner)
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		var body struct {
+			CalendarURL string `json:"calendarUrl"`
+		}
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+		return body.CalendarURL
+	}
+
+	url := rotate()
+	w := serve(t, http.MethodGet, url, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "text/calendar; charset=utf-8", w.Header().Get("Content-Type"))
+	require.Contains(t, w.Body.String(), fmt.Sprintf("UID:booking-%d@air-bnb\r\n", booking.ID))
+	require.Contains(t, w.Body.String(), "DTSTART;VALUE=DATE:"+booking.CheckIn.Format("20060102"))
+	require.Contains(t, w.Body.String(), "STATUS:TENTATIVE")
+
+	// Rotating the token disables the old URL.
+	rotated := rotate()
+	require.Equal(t, http.StatusNotFound, serve(t, http.MethodGet, url, nil).Code)
+	require.Equal(t, http.StatusOK, serve(t, http.MethodGet, rotated, nil).Code)
+
+	withoutToken := rotated[:strings.Index(rotated, "?")]
+	require.Equal(t, http.StatusNotFound, serve(t, http.MethodGet, withoutToken, nil).Code)
+}
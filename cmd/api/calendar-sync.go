@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/air-bnb/internal/data"
+	"github.com/air-bnb/internal/ical"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// maxCalendarBytes is the largest external calendar that is read.
+	maxCalendarBytes = 2 << 20
+	// maxImportedEvents is how many upcoming events one external calendar may block.
+	maxImportedEvents = 2000
+)
+
+var errPrivateAddress = errors.New("calendar URL must not point to a private address")
+
+// newCalendarClient returns the client used to fetch external calendars. Hosts choose the URLs, so
+// it refuses to connect to loopback and private networks, including after redirects.
+func newCalendarClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+				ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() {
+				return errPrivateAddress
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{Transport: transport, Timeout: 15 * time.Second}
+}
+
+// syncCalendarImport fetches the external calendar and reconciles the listing's blocked dates with
+// it. A failed fetch is recorded on the import and the dates it blocked before are kept.
+func (app *application) syncCalendarImport(ctx context.Context, imp *data.CalendarImport) error {
+	events, err := app.fetchCalendar(ctx, imp.URL)
+	if err != nil {
+		if recordErr := app.models.Imports.RecordError(imp, err); recordErr != nil {
+			return recordErr
+		}
+		return err
+	}
+
+	return app.models.Imports.Sync(imp, events)
+}
+
+// fetchCalendar returns the upcoming busy events of the calendar at url. Cancelled events and the
+// ones this API exported itself are left out, so importing our own feed can't block dates twice.
+func (app *application) fetchCalendar(ctx context.Context, url string) ([]data.ImportedEvent, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/calendar")
+
+	resp, err := app.calendarClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("calendar responded with %s", resp.Status)
+	}
+
+	cal, err := ical.Parse(io.LimitReader(resp.Body, maxCalendarBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	seen := make(map[string]bool)
+	var events []data.ImportedEvent
+
+	for _, event := range cal.Events {
+		if event.Status == ical.StatusCancelled || strings.HasSuffix(event.UID, "@air-bnb") {
+			continue
+		}
+		if !event.End.After(event.Start) || !event.End.After(today) || seen[event.UID] {
+			continue
+		}
+		seen[event.UID] = true
+
+		if len(events) == maxImportedEvents {
+			return nil, fmt.Errorf("calendar has more than %d upcoming events", maxImportedEvents)
+		}
+
+		summary := event.Summary
+		if summary == "" {
+			summary = "Imported from external calendar"
+		}
+		events = append(events, data.ImportedEvent{UID: event.UID, StartDate: event.Start, EndDate: event.End, Summary: summary})
+	}
+
+	return events, nil
+}
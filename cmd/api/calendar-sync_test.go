@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/air-bnb/internal/data"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// calendarServer stands in for another platform's calendar feed. Its body and status can be swapped
+// between syncs.
+type calendarServer struct {
+	*httptest.Server
+	body   atomic.Value
+	status atomic.Int64
+}
+
+func newCalendarServer(t *testing.T) *calendarServer {
+	s := &calendarServer{}
+	s.status.Store(http.StatusOK)
+	s.body.Store("")
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/calendar")
+		w.WriteHeader(int(s.status.Load()))
+		fmt.Fprint(w, s.body.Load().(string))
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func icsFeed(events ...string) string {
+	return "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//Other//EN\r\n" + strings.Join(events, "") + "END:VCALENDAR\r\n"
+}
+
+func icsEvent(uid string, start time.Time, nights int) string {
+	return fmt.Sprintf("BEGIN:VEVENT\r\nUID:%s\r\nDTSTART;VALUE=DATE:%s\r\nDTEND;VALUE=DATE:%s\r\nSUMMARY:Reserved\r\nEND:VEVENT\r\n",
+		uid, start.Format("20060102"), start.AddDate(0, 0, nights).Format("20060102"))
+}
+
+func TestSyncCalendarImport(t *testing.T) {
+	server := newCalendarServer(t)
+	client := testApp.calendarClient
+	testApp.calendarClient = server.Client()
+	t.Cleanup(func() { testApp.calendarClient = client })
+
+	owner := createTestUser(t, false)
+	listing := createTestListing(t, owner)
+	start := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 30)
+
+	imp := &data.CalendarImport{ListingID: listing.ID, URL: server.URL + "/feed.ics"}
+	require.NoError(t, testApp.models.Imports.Insert(imp))
+
+	blocks := func() []*data.BlockedDate {
+		blocks, err := testApp.models.Blocks.GetForListing(listing.ID, start.AddDate(0, 0, -60), start.AddDate(0, 0, 60))
+		require.NoError(t, err)
+		return blocks
+	}
+
+	server.body.Store(icsFeed(
+		icsEvent("a@other", start, 2),
+		icsEvent("b@other", start.AddDate(0, 0, 10), 3),
+		icsEvent("past@other", start.AddDate(0, 0, -50), 2),
+		icsEvent("booking-1@air-bnb", start.AddDate(0, 0, 20), 2),
+	))
+	require.NoError(t, testApp.syncCalendarImport(context.Background(), imp))
+	require.Equal(t, int64(2), imp.EventCount)
+	require.NotNil(t, imp.LastSyncedAt)
+	require.Len(t, blocks(), 2)
+	require.Equal(t, imp.ID, *blocks()[0].ImportID)
+
+	// "a" moved and "b" was removed upstream.
+	server.body.Store(icsFeed(icsEvent("a@other", start.AddDate(0, 0, 1), 2)))
+	require.NoError(t, testApp.syncCalendarImport(context.Background(), imp))
+	got := blocks()
+	require.Len(t, got, 1)
+	require.Equal(t, start.AddDate(0, 0, 1), got[0].StartDate.UTC())
+
+	// A failing feed is reported and keeps the dates it blocked.
+	server.status.Store(http.StatusInternalServerError)
+	require.Error(t, testApp.syncCalendarImport(context.Background(), imp))
+	stored, err := testApp.models.Imports.Get(imp.ID, listing.ID)
+	require.NoError(t, err)
+	require.Contains(t, stored.LastError, "500")
+	require.Len(t, blocks(), 1)
+
+	require.NoError(t, testApp.models.Imports.Delete(imp.ID, listing.ID))
+	require.Empty(t, blocks())
+}
+
+func TestNewCalendarClient_RefusesPrivateAddresses(t *testing.T) {
+	server := newCalendarServer(t)
+
+	_, err := newCalendarClient().Get(server.URL)
+	require.True(t, errors.Is(err, errPrivateAddress), err)
+}
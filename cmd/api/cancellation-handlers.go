@@ -0,0 +1,65 @@
+package main
+
+import (
+	"github.com/air-bnb/internal/data"
+	"github.com/air-bnb/internal/validator"
+	"net/http"
+)
+
+// getCancellationPolicyHandler is public so that guests can read the policy before booking. It is
+// visible to whoever can see the listing.
+func (app *application) getCancellationPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	listing, ok := app.readableListing(w, r)
+	if !ok {
+		return
+	}
+
+	policy, err := app.models.Cancellation.Get(listing.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"cancellationPolicy": policy}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) updateCancellationPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	listing, ok := app.ownedListing(w, r)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Name  string                  `json:"name"`
+		Tiers []data.CancellationTier `json:"tiers"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	policy := &data.CancellationPolicy{ListingID: listing.ID, Tiers: input.Tiers}
+	policy.SetName(input.Name)
+
+	v := validator.New()
+	if data.ValidateCancellationPolicy(v, policy); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Cancellation.Upsert(policy)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"cancellationPolicy": policy}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+func TestDeleteCategoryHandler_Authorization(t *testing.T) {
+	user := createTestUser(t, false)
+	admin := createTestUser(t, true)
+	path := "/v1/categories/test-missing-category"
+
+	require.Equal(t, http.StatusUnauthorized, serve(t, http.MethodDelete, path, nil).Code)
+	require.Equal(t, http.StatusForbidden, serve(t, http.MethodDelete, path, &user).Code)
+	require.Equal(t, http.StatusNotFound, serve(t, http.MethodDelete, path, &admin).Code)
+
+	// Categories still in use can't be deleted.
+	createTestListing(t, user)
+	require.Equal(t, http.StatusConflict, serve(t, http.MethodDelete, "/v1/categories/beach", &admin).Code)
+	require.Equal(t, http.StatusOK, serve(t, http.MethodGet, "/v1/categories", nil).Code)
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"errors"
+	"github.com/air-bnb/internal/data"
+	"github.com/air-bnb/internal/validator"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"strconv"
+)
+
+// Co-hosts are managed by the listing's owner alone; co-hosts can't add or remove each other.
+
+func (app *application) getCoHostsHandler(w http.ResponseWriter, r *http.Request) {
+	listing, ok := app.ownedListing(w, r)
+	if !ok {
+		return
+	}
+
+	cohosts, err := app.models.ListingHosts.GetForListing(listing.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"coHosts": cohosts}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// addCoHostHandler adds the user with the given email as a co-host. Adding someone who already
+// co-hosts the listing succeeds without changing anything.
+func (app *application) addCoHostHandler(w http.ResponseWriter, r *http.Request) {
+	listing, ok := app.ownedListing(w, r)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Email string `json:"email"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	if data.ValidateEmail(v, input.Email); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	user, err := app.models.Users.Get(0, input.Email)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			v.AddError("email", "no user with this email address")
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if v.Check(!app.isListingOwner(user, listing), "email", "the owner can't be a co-host"); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.ListingHosts.Insert(listing.ID, user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	cohosts, err := app.models.ListingHosts.GetForListing(listing.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"coHosts": cohosts}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) removeCoHostHandler(w http.ResponseWriter, r *http.Request) {
+	listing, ok := app.ownedListing(w, r)
+	if !ok {
+		return
+	}
+
+	params := chi.URLParam(r, "userId")
+	userID, err := strconv.ParseInt(params, 10, 64)
+	if err != nil || userID < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.ListingHosts.Delete(listing.ID, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "co-host removed successfully"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
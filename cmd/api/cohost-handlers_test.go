@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCoHostHandlers(t *testing.T) {
+	owner := createTestUser(t, false)
+	cohost := createTestUser(t, false)
+	listing := createTestListing(t, owner)
+	path := fmt.Sprintf("/v1/listings/%d/co-hosts", listing.ID)
+
+	add := func(user *testUser, email string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(fmt.Sprintf(`{"email": %q}`, email)))
+		r.AddCookie(testApp.sessionCookie(user.token, time.Now().Add(time.Hour)))
+
+		w := httptest.NewRecorder()
+		testApp.routes().ServeHTTP(w, r)
+		return w
+	}
+
+	require.Equal(t, http.StatusForbidden, add(&cohost, cohost.Email).Code)
+	require.Equal(t, http.StatusUnprocessableEntity, add(&owner, "nobody-"+cohost.Email).Code)
+	require.Equal(t, http.StatusUnprocessableEntity, add(&owner, owner.Email).Code)
+	require.Equal(t, http.StatusCreated, add(&owner, cohost.Email).Code)
+
+	ok, err := testApp.models.ListingHosts.IsCoHost(listing.ID, cohost.ID)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// A co-host helps run the listing but can't manage who else does.
+	require.Equal(t, http.StatusForbidden, serve(t, http.MethodGet, path, &cohost).Code)
+	require.Equal(t, http.StatusOK, serve(t, http.MethodGet, path, &owner).Code)
+
+	remove := fmt.Sprintf("%s/%d", path, cohost.ID)
+	require.Equal(t, http.StatusForbidden, serve(t, http.MethodDelete, remove, &cohost).Code)
+	require.Equal(t, http.StatusOK, serve(t, http.MethodDelete, remove, &owner).Code)
+	require.Equal(t, http.StatusNotFound, serve(t, http.MethodDelete, remove, &owner).Code)
+}
@@ -63,3 +63,62 @@ func (app *application) alreadyHaveSessionResponse(w http.ResponseWriter, r *htt
 	message := "you are already authenticated, this resource is only for the guests"
 	app.errorResponse(w, r, http.StatusUnauthorized, message)
 }
+
+func (app *application) bookingConflictResponse(w http.ResponseWriter, r *http.Request, err error) {
+	app.errorResponse(w, r, http.StatusConflict, err.Error())
+}
+
+func (app *application) notPermittedResponse(w http.ResponseWriter, r *http.Request) {
+	message := "your user account doesn't have the necessary permissions to access this resource"
+	app.errorResponse(w, r, http.StatusForbidden, message)
+}
+
+func (app *application) editConflictResponse(w http.ResponseWriter, r *http.Request) {
+	message := "unable to update the record due to an edit conflict, please try again"
+	app.errorResponse(w, r, http.StatusConflict, message)
+}
+
+func (app *application) invalidTransitionResponse(w http.ResponseWriter, r *http.Request, from, to string) {
+	message := fmt.Sprintf("a %s booking cannot be moved to %s", from, to)
+	app.errorResponse(w, r, http.StatusConflict, message)
+}
+
+func (app *application) invalidListingTransitionResponse(w http.ResponseWriter, r *http.Request, from, to string) {
+	message := fmt.Sprintf("a %s listing cannot be moved to %s", from, to)
+	app.errorResponse(w, r, http.StatusConflict, message)
+}
+
+func (app *application) paymentDeclinedResponse(w http.ResponseWriter, r *http.Request) {
+	message := "the payment was declined, please try a different payment method"
+	app.errorResponse(w, r, http.StatusPaymentRequired, message)
+}
+
+func (app *application) pendingChangeResponse(w http.ResponseWriter, r *http.Request) {
+	message := "this booking already has a change waiting for the host, wait for it to be approved or declined"
+	app.errorResponse(w, r, http.StatusConflict, message)
+}
+
+func (app *application) requestExpiredResponse(w http.ResponseWriter, r *http.Request) {
+	message := "this booking request has expired and can no longer be accepted"
+	app.errorResponse(w, r, http.StatusConflict, message)
+}
+
+func (app *application) datesHeldResponse(w http.ResponseWriter, r *http.Request) {
+	message := "another guest is checking out these dates, please try again in a few minutes"
+	app.errorResponse(w, r, http.StatusConflict, message)
+}
+
+func (app *application) categoryInUseResponse(w http.ResponseWriter, r *http.Request) {
+	message := "the category still has listings, move them to another category first"
+	app.errorResponse(w, r, http.StatusConflict, message)
+}
+
+func (app *application) unknownPaymentResponse(w http.ResponseWriter, r *http.Request) {
+	message := "the payment provider has no record of this booking's payment, so it can't be settled"
+	app.errorResponse(w, r, http.StatusConflict, message)
+}
+
+func (app *application) changeRepricedResponse(w http.ResponseWriter, r *http.Request) {
+	message := "the price of the new stay has changed since the change was requested, so it was declined; the guest can request it again"
+	app.errorResponse(w, r, http.StatusConflict, message)
+}
@@ -4,8 +4,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/air-bnb/internal/data"
 	"github.com/air-bnb/internal/validator"
+	"github.com/go-chi/chi/v5"
 	"io"
+	"math"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -95,6 +98,52 @@ func (app *application) readString(qs url.Values, key string, defaultValue strin
 	return s
 }
 
+// readStrings reads a multi-valued parameter given either repeated (?key=a&key=b) or comma-separated
+// (?key=a,b). Blank values are dropped.
+func (app *application) readStrings(qs url.Values, key string) []string {
+	var values []string
+	for _, param := range qs[key] {
+		for _, value := range strings.Split(param, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+// readCursor decodes a pagination cursor from a previous page's metadata, or returns nil if there is
+// none.
+func (app *application) readCursor(qs url.Values, key string, v *validator.Validator) *data.Cursor {
+	s := qs.Get(key)
+	if s == "" {
+		return nil
+	}
+	cursor, err := data.DecodeCursor(s)
+	if err != nil {
+		v.AddError(key, "Must be a cursor from a previous page")
+		return nil
+	}
+	return cursor
+}
+
+// readPaging reads the sort and, when the client asks for pages with page, page_size or cursor, the
+// paging of a list that is otherwise returned whole. It reports whether v is still valid.
+func (app *application) readPaging(qs url.Values, defaultSort string, sortSafelist []string, v *validator.Validator) (data.Filters, bool) {
+	filters := data.Filters{Sort: app.readString(qs, "sort", defaultSort), SortSafelist: sortSafelist}
+
+	if qs.Has("page") || qs.Has("page_size") || qs.Has("cursor") {
+		filters.Page = app.readInt(qs, "page", 1, v)
+		filters.PageSize = app.readInt(qs, "page_size", 20, v)
+		filters.Cursor = app.readCursor(qs, "cursor", v)
+		data.ValidateFilters(v, filters)
+	} else {
+		v.Check(validator.PermittedValue(filters.Sort, sortSafelist...), "sort", "invalid sort value")
+	}
+
+	return filters, v.Valid()
+}
+
 func (app *application) readInt(qs url.Values, key string, defaultValue int, v *validator.Validator) int {
 	s := qs.Get(key)
 	if s == "" {
@@ -107,3 +156,118 @@ func (app *application) readInt(qs url.Values, key string, defaultValue int, v *
 	}
 	return i
 }
+
+func (app *application) readFloat(qs url.Values, key string, defaultValue float64, v *validator.Validator) float64 {
+	s := qs.Get(key)
+	if s == "" {
+		return defaultValue
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		v.AddError(key, "Must be a number")
+		return defaultValue
+	}
+	return f
+}
+
+// readBoundingBox reads a box given as "minLng,minLat,maxLng,maxLat", the order GeoJSON uses.
+func (app *application) readBoundingBox(qs url.Values, key string, v *validator.Validator) *data.BoundingBox {
+	s := qs.Get(key)
+	if s == "" {
+		return nil
+	}
+
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		v.AddError(key, "Must be four comma-separated numbers: minLng,minLat,maxLng,maxLat")
+		return nil
+	}
+
+	var corners [4]float64
+	for i, part := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			v.AddError(key, "Must be four comma-separated numbers: minLng,minLat,maxLng,maxLat")
+			return nil
+		}
+		corners[i] = f
+	}
+
+	return &data.BoundingBox{MinLng: corners[0], MinLat: corners[1], MaxLng: corners[2], MaxLat: corners[3]}
+}
+
+func (app *application) readDate(qs url.Values, key string, v *validator.Validator) time.Time {
+	return app.parseDate(qs.Get(key), key, v)
+}
+
+func (app *application) parseDate(s string, key string, v *validator.Validator) time.Time {
+	if s == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse(data.DateLayout, s)
+	if err != nil {
+		v.AddError(key, "Must be a date in YYYY-MM-DD format")
+		return time.Time{}
+	}
+	return t
+}
+
+// ownedListing loads the listing named by the listingId URL parameter and checks that the session
+// user owns it. On failure it has already written the error response and returns false.
+func (app *application) ownedListing(w http.ResponseWriter, r *http.Request) (*data.Listing, bool) {
+	params := chi.URLParam(r, "listingId")
+	id, err := strconv.ParseInt(params, 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return nil, false
+	}
+
+	listing, err := app.models.Listings.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return nil, false
+	}
+
+	if !app.isListingOwner(app.contextGetUser(r), listing) {
+		app.notPermittedResponse(w, r)
+		return nil, false
+	}
+
+	return listing, true
+}
+
+// listingETag is the entity tag for a version of a listing. Clients send it back in If-Match so an
+// update doesn't overwrite changes they haven't seen.
+func listingETag(listing *data.Listing) string {
+	return fmt.Sprintf(`"%d"`, listing.Version)
+}
+
+// listingHeaders are the response headers for a single listing.
+func listingHeaders(listing *data.Listing) http.Header {
+	headers := make(http.Header)
+	headers.Set("ETag", listingETag(listing))
+	return headers
+}
+
+// ifMatch reports whether the request's If-Match header allows changing the listing. A request
+// without the header always may. Weak tags never match, as RFC 9110 asks for a strong comparison.
+func ifMatch(r *http.Request, listing *data.Listing) bool {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return true
+	}
+
+	etag := listingETag(listing)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"github.com/air-bnb/internal/data"
+	"github.com/air-bnb/internal/validator"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// createHoldHandler keeps the dates aside for data.HoldTTL while the guest checks out. Creating a
+// booking for the same dates releases the hold.
+func (app *application) createHoldHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "listingId"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		StartDate time.Time `json:"startDate"`
+		EndDate   time.Time `json:"endDate"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	listing, err := app.models.Listings.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	if listing.Status != data.ListingPublished {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	ok, err := app.canBookListing(app.contextGetUser(r), listing)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if !ok {
+		app.notPermittedResponse(w, r)
+		return
+	}
+
+	v := validator.New()
+	if data.ValidateQuoteRequest(v, data.QuoteRequest{CheckIn: input.StartDate, CheckOut: input.EndDate, Guests: 1}); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	rules, err := app.models.StayRules.Get(id)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	blocks, err := app.models.Blocks.GetForListing(id, input.StartDate, input.EndDate)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if data.ValidateStay(v, rules, blocks, input.StartDate, input.EndDate, time.Now()); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	hold := &data.Hold{
+		ListingID: id,
+		GuestID:   app.contextGetUser(r).ID,
+		CheckIn:   input.StartDate,
+		CheckOut:  input.EndDate,
+	}
+
+	err = app.models.Holds.Insert(hold)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDatesHeld):
+			app.datesHeldResponse(w, r)
+		case errors.Is(err, data.ErrBookingConflict):
+			app.bookingConflictResponse(w, r, err)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"hold": hold}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteHoldHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "holdId"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.Holds.Delete(id, app.contextGetUser(r).ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "hold released"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCreateHoldHandler_Hosts(t *testing.T) {
+	owner := createTestUser(t, false)
+	cohost := createTestUser(t, false)
+	guest := createTestUser(t, false)
+
+	listing := createTestListing(t, owner)
+	require.NoError(t, testApp.models.ListingHosts.Insert(listing.ID, cohost.ID))
+
+	checkIn := time.Now().AddDate(2, 0, 0).Truncate(24 * time.Hour)
+	body := fmt.Sprintf(`{"startDate": %q, "endDate": %q}`,
+		checkIn.Format(time.RFC3339), checkIn.AddDate(0, 0, 2).Format(time.RFC3339))
+
+	hold := func(user *testUser) int {
+		r := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/v1/listings/%d/holds", listing.ID), strings.NewReader(body))
+		r.AddCookie(testApp.sessionCookie(user.token, time.Now().Add(time.Hour)))
+
+		w := httptest.NewRecorder()
+		testApp.routes().ServeHTTP(w, r)
+		return w.Code
+	}
+
+	require.Equal(t, http.StatusForbidden, hold(&owner))
+	require.Equal(t, http.StatusForbidden, hold(&cohost))
+	require.Equal(t, http.StatusCreated, hold(&guest))
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"errors"
+	"github.com/air-bnb/internal/data"
+	"github.com/air-bnb/internal/validator"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"strconv"
+)
+
+// getListingCompletenessHandler tells the host what the listing still needs before it can be
+// published.
+func (app *application) getListingCompletenessHandler(w http.ResponseWriter, r *http.Request) {
+	listing, ok := app.ownedListing(w, r)
+	if !ok {
+		return
+	}
+
+	images, err := app.models.Images.GetForListing(listing.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	data.ValidateListingComplete(v, listing, len(images))
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"complete": v.Valid(), "missing": v.Errors}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// publishListingHandler sends a complete draft for review. An unlisted listing has been reviewed
+// already, so it goes straight back on search.
+func (app *application) publishListingHandler(w http.ResponseWriter, r *http.Request) {
+	app.transitionListing(w, r, false, func(listing *data.Listing) string {
+		if listing.Status == data.ListingUnlisted {
+			return data.ListingPublished
+		}
+		return data.ListingPendingReview
+	})
+}
+
+func (app *application) unlistListingHandler(w http.ResponseWriter, r *http.Request) {
+	app.transitionListing(w, r, false, func(*data.Listing) string { return data.ListingUnlisted })
+}
+
+func (app *application) archiveListingHandler(w http.ResponseWriter, r *http.Request) {
+	app.transitionListing(w, r, false, func(*data.Listing) string { return data.ListingArchived })
+}
+
+// restoreListingHandler brings an archived listing back as a draft.
+func (app *application) restoreListingHandler(w http.ResponseWriter, r *http.Request) {
+	app.transitionListing(w, r, false, func(*data.Listing) string { return data.ListingDraft })
+}
+
+func (app *application) approveListingHandler(w http.ResponseWriter, r *http.Request) {
+	app.transitionListing(w, r, true, func(*data.Listing) string { return data.ListingPublished })
+}
+
+// rejectListingHandler sends a listing under review back to its host as a draft.
+func (app *application) rejectListingHandler(w http.ResponseWriter, r *http.Request) {
+	app.transitionListing(w, r, true, func(*data.Listing) string { return data.ListingDraft })
+}
+
+// transitionListing moves the listing in the URL to the status next picks for it. Hosts move their
+// own listings; admin moves sit behind requireAdmin and work on any listing. A listing only goes
+// towards search once it is complete.
+func (app *application) transitionListing(w http.ResponseWriter, r *http.Request, admin bool, next func(*data.Listing) string) {
+	var listing *data.Listing
+	if admin {
+		id, err := strconv.ParseInt(chi.URLParam(r, "listingId"), 10, 64)
+		if err != nil || id < 1 {
+			app.notFoundResponse(w, r)
+			return
+		}
+
+		listing, err = app.models.Listings.Get(id)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrRecordNotFound):
+				app.notFoundResponse(w, r)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+	} else {
+		var ok bool
+		listing, ok = app.ownedListing(w, r)
+		if !ok {
+			return
+		}
+	}
+
+	if !ifMatch(r, listing) {
+		app.editConflictResponse(w, r)
+		return
+	}
+
+	from, status := listing.Status, next(listing)
+	err := listing.Transition(status)
+	if err != nil {
+		app.invalidListingTransitionResponse(w, r, from, status)
+		return
+	}
+
+	if listing.NeedsComplete() {
+		images, err := app.models.Images.GetForListing(listing.ID)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		v := validator.New()
+		if data.ValidateListingComplete(v, listing, len(images)); !v.Valid() {
+			app.failedValidationResponse(w, r, v.Errors)
+			return
+		}
+	}
+
+	err = app.models.Listings.UpdateStatus(listing, from)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"listing": listing}, listingHeaders(listing))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"github.com/air-bnb/internal/data"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+func TestListingWorkflow(t *testing.T) {
+	host := createTestUser(t, false)
+	stranger := createTestUser(t, false)
+	admin := createTestUser(t, true)
+
+	listing := createTestListing(t, host)
+	listing.Status = data.ListingDraft
+	require.NoError(t, testApp.models.Listings.UpdateStatus(listing, data.ListingPublished))
+	path := fmt.Sprintf("/v1/listings/%d", listing.ID)
+
+	// Drafts are only visible to their host.
+	require.Equal(t, http.StatusNotFound, serve(t, http.MethodGet, path, nil).Code)
+	require.Equal(t, http.StatusNotFound, serve(t, http.MethodGet, path, &stranger).Code)
+	require.Equal(t, http.StatusOK, serve(t, http.MethodGet, path, &host).Code)
+	for _, sub := range []string{"/availability", "/quote?checkIn=2030-01-10&checkOut=2030-01-12"} {
+		require.Equal(t, http.StatusNotFound, serve(t, http.MethodGet, path+sub, nil).Code)
+		require.Equal(t, http.StatusNotFound, serve(t, http.MethodGet, path+sub, &stranger).Code)
+		require.Equal(t, http.StatusOK, serve(t, http.MethodGet, path+sub, &host).Code)
+	}
+
+	// Without coordinates and photos the draft can't go to review.
+	require.Equal(t, http.StatusUnprocessableEntity, serve(t, http.MethodPatch, path+"/publish", &host).Code)
+
+	lat, lng := 45.1, 13.6
+	listing.Location.Lat, listing.Location.Lng = &lat, &lng
+	require.NoError(t, testApp.models.Listings.Update(listing))
+	require.NoError(t, testApp.models.Images.Insert(&data.Image{ListingID: listing.ID, Url: "https://example.com/a.jpg"}))
+
+	require.Equal(t, http.StatusForbidden, serve(t, http.MethodPatch, path+"/publish", &stranger).Code)
+	require.Equal(t, http.StatusOK, serve(t, http.MethodPatch, path+"/publish", &host).Code)
+	require.Equal(t, http.StatusConflict, serve(t, http.MethodPatch, path+"/publish", &host).Code)
+
+	require.Equal(t, http.StatusForbidden, serve(t, http.MethodPatch, path+"/approve", &host).Code)
+	require.Equal(t, http.StatusOK, serve(t, http.MethodPatch, path+"/approve", &admin).Code)
+	require.Equal(t, http.StatusOK, serve(t, http.MethodGet, path, &stranger).Code)
+
+	published, err := testApp.models.Listings.Get(listing.ID)
+	require.NoError(t, err)
+	require.Equal(t, data.ListingPublished, published.Status)
+	require.NotNil(t, published.PublishedAt)
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	"net/http"
 	"strconv"
+	"strings"
 )
 
 type Location struct {
@@ -19,48 +20,73 @@ type Location struct {
 
 func (app *application) createListingHandler(w http.ResponseWriter, r *http.Request) {
 	var input struct {
-		Bathrooms   int64    `json:"bathrooms"`
-		Bedrooms    int64    `json:"bedrooms"`
-		Category    string   `json:"category"`
-		Guests      int64    `json:"guests"`
-		Price       string   `json:"price"`
-		Title       string   `json:"title"`
-		Description string   `json:"description"`
-		Images      []string `json:"images"`
-		Location    Location `json:"location"`
+		Bathrooms          int64    `json:"bathrooms"`
+		Bedrooms           int64    `json:"bedrooms"`
+		Category           string   `json:"category"`
+		Guests             int64    `json:"guests"`
+		Price              string   `json:"price"`
+		CleaningFee        int64    `json:"cleaningFee"`
+		InstantBook        bool     `json:"instantBook"`
+		RequestExpiryHours int64    `json:"requestExpiryHours"`
+		MaxPets            int64    `json:"maxPets"`
+		BaseOccupancy      int64    `json:"baseOccupancy"`
+		ExtraGuestFee      int64    `json:"extraGuestFee"`
+		Title              string   `json:"title"`
+		Description        string   `json:"description"`
+		Images             []string `json:"images"`
+		Amenities          []string `json:"amenities"`
+		Location           Location `json:"location"`
 	}
 	err := app.readJSON(w, r, &input)
-	if err != nil {
-		app.badRequestResponse(w, r, err)
-	}
-
-	price, err := strconv.ParseInt(input.Price, 10, 64)
 	if err != nil {
 		app.badRequestResponse(w, r, err)
 		return
 	}
 
-	location := &data.Location{
-		Flag:   input.Location.Flag,
-		Label:  input.Location.Label,
-		Lat:    input.Location.LatLng[0],
-		Lng:    input.Location.LatLng[1],
-		Region: input.Location.Region,
+	// A new listing is a draft, so anything may still be missing.
+	var price int64
+	if input.Price != "" {
+		price, err = strconv.ParseInt(input.Price, 10, 64)
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
 	}
 
+	v := validator.New()
+	location := app.listingLocation(input.Location, v)
+
 	listing := &data.Listing{
-		Bathrooms:   input.Bathrooms,
-		Bedrooms:    input.Bedrooms,
-		Category:    input.Category,
-		Guests:      input.Guests,
-		Price:       price,
-		Title:       input.Title,
-		Description: input.Description,
-		OwnerID:     app.contextGetUser(r).ID,
-		Location:    *location,
+		Bathrooms:          input.Bathrooms,
+		Bedrooms:           input.Bedrooms,
+		Category:           input.Category,
+		Guests:             input.Guests,
+		Price:              price,
+		CleaningFee:        input.CleaningFee,
+		InstantBook:        input.InstantBook,
+		RequestExpiryHours: input.RequestExpiryHours,
+		MaxPets:            input.MaxPets,
+		BaseOccupancy:      input.BaseOccupancy,
+		ExtraGuestFee:      input.ExtraGuestFee,
+		Title:              input.Title,
+		Description:        input.Description,
+		Amenities:          input.Amenities,
+		OwnerID:            app.contextGetUser(r).ID,
+		Location:           location,
+	}
+
+	for _, count := range []*int64{&listing.Bedrooms, &listing.Bathrooms, &listing.Guests} {
+		if *count == 0 {
+			*count = 1
+		}
+	}
+	if listing.RequestExpiryHours == 0 {
+		listing.RequestExpiryHours = data.DefaultRequestExpiryHours
+	}
+	if listing.BaseOccupancy == 0 {
+		listing.BaseOccupancy = listing.Guests
 	}
 
-	v := validator.New()
 	data.ValidateListing(v, listing)
 	if !v.Valid() {
 		app.failedValidationResponse(w, r, v.Errors)
@@ -69,7 +95,16 @@ func (app *application) createListingHandler(w http.ResponseWriter, r *http.Requ
 
 	err = app.models.Listings.Insert(listing)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		switch {
+		case errors.Is(err, data.ErrUnknownAmenity):
+			v.AddError("amenities", "must only contain amenities from the catalogue")
+			app.failedValidationResponse(w, r, v.Errors)
+		case errors.Is(err, data.ErrUnknownCategory):
+			v.AddError("category", "must be a category from the taxonomy")
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
 		return
 	}
 
@@ -85,7 +120,7 @@ func (app *application) createListingHandler(w http.ResponseWriter, r *http.Requ
 		}
 	}
 
-	err = app.writeJSON(w, http.StatusCreated, envelope{"id": listing.ID}, nil)
+	err = app.writeJSON(w, http.StatusCreated, envelope{"id": listing.ID, "status": listing.Status}, listingHeaders(listing))
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
@@ -114,6 +149,16 @@ func (app *application) getListingHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	ok, err := app.canReadListing(app.contextGetUser(r), listing)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if !ok {
+		app.notFoundResponse(w, r)
+		return
+	}
+
 	images, err := app.models.Images.GetForListing(listing.ID)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
@@ -121,7 +166,7 @@ func (app *application) getListingHandler(w http.ResponseWriter, r *http.Request
 	}
 	listing.Images = images
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"listing": listing}, nil)
+	err = app.writeJSON(w, http.StatusOK, envelope{"listing": listing}, listingHeaders(listing))
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
@@ -129,9 +174,24 @@ func (app *application) getListingHandler(w http.ResponseWriter, r *http.Request
 
 func (app *application) getAllUserListingsHandler(w http.ResponseWriter, r *http.Request) {
 	session := app.contextGetUser(r)
-	listings, err := app.models.Listings.AllUserListings(session.ID)
+
+	v := validator.New()
+	filters, ok := app.readPaging(r.URL.Query(), "-created_at", []string{"id", "-id", "created_at", "-created_at",
+		"title", "-title", "price", "-price"}, v)
+	if !ok {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	listings, metadata, err := app.models.Listings.AllUserListings(session.ID, filters)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		switch {
+		case errors.Is(err, data.ErrInvalidCursor):
+			v.AddError("cursor", "Must be a cursor from a previous page")
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
 		return
 	}
 
@@ -144,7 +204,7 @@ func (app *application) getAllUserListingsHandler(w http.ResponseWriter, r *http
 		listing.Images = images
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"listings": listings}, nil)
+	err = app.writeJSON(w, http.StatusOK, envelope{"listings": listings, "metadata": metadata}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
@@ -288,25 +348,72 @@ func (app *application) getAllListingsHandler(w http.ResponseWriter, r *http.Req
 
 	var meta struct {
 		data.Filters
-		Search string
+		Search data.ListingSearch
 	}
 
 	v := validator.New()
 	qs := r.URL.Query()
 
-	meta.Filters.Sort = app.readString(qs, "sort", "-id")
-	meta.Filters.SortSafelist = []string{"id", "-id", "created_at", "-created_at", "title", "-title"}
+	meta.Search.Text = app.readString(qs, "search", "")
+
+	// Searches are ranked by relevance unless the client asks for another order.
+	defaultSort := "-id"
+	if meta.Search.Text != "" {
+		defaultSort = "-relevance"
+	}
+	meta.Filters.Sort = app.readString(qs, "sort", defaultSort)
+	meta.Filters.SortSafelist = []string{"id", "-id", "created_at", "-created_at", "title", "-title",
+		"price", "-price", "distance", "-distance", "relevance", "-relevance"}
 	meta.Filters.Page = app.readInt(qs, "page", 1, v)
 	meta.Filters.PageSize = app.readInt(qs, "page_size", 20, v)
-	meta.Search = app.readString(qs, "search", "")
+	meta.Filters.Cursor = app.readCursor(qs, "cursor", v)
+
+	meta.Filters.MinPrice = int64(app.readInt(qs, "price_min", 0, v))
+	meta.Filters.MaxPrice = int64(app.readInt(qs, "price_max", 0, v))
+	meta.Filters.MinBedrooms = int64(app.readInt(qs, "min_bedrooms", 0, v))
+	meta.Filters.MinBathrooms = int64(app.readInt(qs, "min_bathrooms", 0, v))
+	meta.Filters.Guests = int64(app.readInt(qs, "guests", 0, v))
+	meta.Filters.Categories = app.readStrings(qs, "category")
+	meta.Filters.Flag = app.readString(qs, "flag", "")
+	meta.Filters.Region = app.readString(qs, "region", "")
+	meta.Filters.Amenities = app.readStrings(qs, "amenities")
+	meta.Filters.CheckIn = app.readDate(qs, "check_in", v)
+	meta.Filters.CheckOut = app.readDate(qs, "check_out", v)
+
+	if qs.Has("lat") || qs.Has("lng") {
+		v.Check(qs.Get("lat") != "" && qs.Get("lng") != "", "lat", "lat and lng must be given together")
+		meta.Search.Center = &data.GeoPoint{Lat: app.readFloat(qs, "lat", 0, v), Lng: app.readFloat(qs, "lng", 0, v)}
+	}
+	meta.Search.RadiusKm = app.readFloat(qs, "radius_km", 0, v)
+	meta.Search.BBox = app.readBoundingBox(qs, "bbox", v)
+
+	if strings.TrimPrefix(meta.Filters.Sort, "-") == "distance" {
+		v.Check(meta.Search.Center != nil || meta.Search.BBox != nil, "sort", "sorting by distance needs lat and lng or bbox")
+	}
+	if strings.TrimPrefix(meta.Filters.Sort, "-") == "relevance" {
+		v.Check(meta.Search.Text != "", "sort", "sorting by relevance needs a search term")
+	}
 
 	data.ValidateFilters(v, meta.Filters)
+	data.ValidateListingSearch(v, meta.Search)
 	if !v.Valid() {
 		app.failedValidationResponse(w, r, v.Errors)
 		return
 	}
 
 	listings, metadata, err := app.models.Listings.GetAll(meta.Search, meta.Filters)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrInvalidCursor):
+			v.AddError("cursor", "Must be a cursor from a previous page")
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	facets, err := app.models.Listings.Facets(meta.Search, meta.Filters.ListingFilters)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
@@ -321,59 +428,195 @@ func (app *application) getAllListingsHandler(w http.ResponseWriter, r *http.Req
 		listing.Images = images
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"listings": listings, "metadata": metadata}, nil)
+	err = app.writeJSON(w, http.StatusOK, envelope{"listings": listings, "metadata": metadata, "facets": facets}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
 }
 
+// updateListingHandler changes only the fields the owner sent, so a draft can be filled in one step
+// at a time. Listings that are public, or waiting to be, must stay complete.
 func (app *application) updateListingHandler(w http.ResponseWriter, r *http.Request) {
-	params := chi.URLParam(r, "listingId")
-	id, err := strconv.ParseInt(params, 10, 64)
+	listing, ok := app.ownedListing(w, r)
+	if !ok {
+		return
+	}
+	if !ifMatch(r, listing) {
+		app.editConflictResponse(w, r)
+		return
+	}
+
+	var input struct {
+		Title              *string   `json:"title"`
+		Description        *string   `json:"description"`
+		Category           *string   `json:"category"`
+		Bedrooms           *int64    `json:"bedrooms"`
+		Bathrooms          *int64    `json:"bathrooms"`
+		Guests             *int64    `json:"guests"`
+		Location           *Location `json:"location"`
+		Price              *float64  `json:"price"`
+		CleaningFee        *int64    `json:"cleaningFee"`
+		InstantBook        *bool     `json:"instantBook"`
+		RequestExpiryHours *int64    `json:"requestExpiryHours"`
+		MaxPets            *int64    `json:"maxPets"`
+		BaseOccupancy      *int64    `json:"baseOccupancy"`
+		ExtraGuestFee      *int64    `json:"extraGuestFee"`
+		Amenities          []string  `json:"amenities"`
+	}
+
+	err := app.readJSON(w, r, &input)
 	if err != nil {
 		app.badRequestResponse(w, r, err)
 		return
 	}
-	if id < 1 {
+
+	v := validator.New()
+
+	if input.Title != nil {
+		listing.Title = *input.Title
+	}
+	if input.Description != nil {
+		listing.Description = *input.Description
+	}
+	if input.Category != nil {
+		listing.Category = *input.Category
+	}
+	if input.Bedrooms != nil {
+		listing.Bedrooms = *input.Bedrooms
+	}
+	if input.Bathrooms != nil {
+		listing.Bathrooms = *input.Bathrooms
+	}
+	if input.Guests != nil {
+		listing.Guests = *input.Guests
+	}
+	if input.Location != nil {
+		listing.Location = app.listingLocation(*input.Location, v)
+	}
+	if input.Price != nil {
+		listing.Price = int64(*input.Price)
+	}
+	if input.CleaningFee != nil {
+		listing.CleaningFee = *input.CleaningFee
+	}
+	if input.InstantBook != nil {
+		listing.InstantBook = *input.InstantBook
+	}
+	if input.RequestExpiryHours != nil {
+		listing.RequestExpiryHours = *input.RequestExpiryHours
+	}
+	if input.MaxPets != nil {
+		listing.MaxPets = *input.MaxPets
+	}
+	if input.BaseOccupancy != nil {
+		listing.BaseOccupancy = *input.BaseOccupancy
+	}
+	if input.ExtraGuestFee != nil {
+		listing.ExtraGuestFee = *input.ExtraGuestFee
+	}
+	if input.Amenities != nil {
+		listing.Amenities = input.Amenities
+	}
+
+	data.ValidateListing(v, listing)
+	if listing.NeedsComplete() {
+		images, err := app.models.Images.GetForListing(listing.ID)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+		data.ValidateListingComplete(v, listing, len(images))
+	}
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Listings.Update(listing)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrUnknownAmenity):
+			v.AddError("amenities", "must only contain amenities from the catalogue")
+			app.failedValidationResponse(w, r, v.Errors)
+		case errors.Is(err, data.ErrUnknownCategory):
+			v.AddError("category", "must be a category from the taxonomy")
+			app.failedValidationResponse(w, r, v.Errors)
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"listing": listing}, listingHeaders(listing))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// updateListingServiceFeeHandler sets the platform's service fee on a listing. Hosts can't change it,
+// so only admins reach this handler.
+func (app *application) updateListingServiceFeeHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "listingId"), 10, 64)
+	if err != nil || id < 1 {
 		app.notFoundResponse(w, r)
 		return
 	}
 
 	var input struct {
-		Title       string  `json:"title"`
-		Description string  `json:"description"`
-		Price       float64 `json:"price"`
+		ServiceFeePercent *int64 `json:"serviceFeePercent"`
 	}
 
 	err = app.readJSON(w, r, &input)
 	if err != nil {
 		app.badRequestResponse(w, r, err)
+		return
 	}
 
-	listing, err := app.models.Listings.Get(id)
+	v := validator.New()
+	v.Check(input.ServiceFeePercent != nil, "serviceFeePercent", "must be provided")
+	if input.ServiceFeePercent != nil {
+		data.ValidateServiceFee(v, *input.ServiceFeePercent)
+	}
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	listing, err := app.models.Listings.UpdateServiceFee(id, *input.ServiceFeePercent)
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
 			app.notFoundResponse(w, r)
 		default:
 			app.serverErrorResponse(w, r, err)
-
 		}
 		return
 	}
 
-	listing.Title = input.Title
-	listing.Description = input.Description
-	listing.Price = int64(input.Price)
-
-	err = app.models.Listings.Update(listing)
+	err = app.writeJSON(w, http.StatusOK, envelope{"listing": listing}, listingHeaders(listing))
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
-		return
 	}
+}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"listing": listing}, nil)
-	if err != nil {
-		app.serverErrorResponse(w, r, err)
+// listingLocation converts a location from a request. The coordinates may be left out of a draft.
+func (app *application) listingLocation(input Location, v *validator.Validator) data.Location {
+	location := data.Location{
+		Flag:   input.Flag,
+		Label:  input.Label,
+		Region: input.Region,
+		Value:  input.Value,
+	}
+
+	switch len(input.LatLng) {
+	case 0:
+	case 2:
+		location.Lat, location.Lng = &input.LatLng[0], &input.LatLng[1]
+	default:
+		v.AddError("location.latlng", "must be a latitude and a longitude")
 	}
+
+	return location
 }
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"github.com/air-bnb/internal/data"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIfMatch(t *testing.T) {
+	listing := &data.Listing{Version: 3}
+
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"", true},
+		{`"3"`, true},
+		{`"2", "3"`, true},
+		{"*", true},
+		{`"2"`, false},
+		{`W/"3"`, false},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPatch, "/", nil)
+		if tt.header != "" {
+			r.Header.Set("If-Match", tt.header)
+		}
+		require.Equal(t, tt.want, ifMatch(r, listing), tt.header)
+	}
+}
+
+func TestUpdateListingHandler_IfMatch(t *testing.T) {
+	host := createTestUser(t, false)
+	listing := createTestListing(t, host)
+	lat, lng := 45.1, 13.6
+	listing.Location.Lat, listing.Location.Lng = &lat, &lng
+	require.NoError(t, testApp.models.Listings.Update(listing))
+	require.NoError(t, testApp.models.Images.Insert(&data.Image{ListingID: listing.ID, Url: "https://example.com/a.jpg"}))
+	path := fmt.Sprintf("/v1/listings/%d", listing.ID)
+
+	patch := func(user *testUser, ifMatch, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPatch, path, strings.NewReader(body))
+		r.AddCookie(testApp.sessionCookie(user.token, time.Now().Add(time.Hour)))
+		if ifMatch != "" {
+			r.Header.Set("If-Match", ifMatch)
+		}
+
+		w := httptest.NewRecorder()
+		testApp.routes().ServeHTTP(w, r)
+		return w
+	}
+
+	stranger := createTestUser(t, false)
+	require.Equal(t, http.StatusForbidden, patch(&stranger, "", `{"title": "Mine now"}`).Code)
+
+	etag := serve(t, http.MethodGet, path, nil).Header().Get("ETag")
+	require.Equal(t, `"2"`, etag)
+
+	w := patch(&host, etag, `{"bedrooms": 3}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, `"3"`, w.Header().Get("ETag"))
+
+	// A second edit from the same stale copy would lose the first one.
+	require.Equal(t, http.StatusConflict, patch(&host, etag, `{"title": "Stale"}`).Code)
+
+	updated, err := testApp.models.Listings.Get(listing.ID)
+	require.NoError(t, err)
+	require.EqualValues(t, 3, updated.Bedrooms)
+	require.Equal(t, listing.Title, updated.Title)
+	require.Equal(t, listing.Category, updated.Category)
+}
+
+func TestUpdateListingServiceFeeHandler(t *testing.T) {
+	host := createTestUser(t, false)
+	admin := createTestUser(t, true)
+	listing := createTestListing(t, host)
+
+	patch := func(user *testUser, path, body string) int {
+		r := httptest.NewRequest(http.MethodPatch, path, strings.NewReader(body))
+		r.AddCookie(testApp.sessionCookie(user.token, time.Now().Add(time.Hour)))
+
+		w := httptest.NewRecorder()
+		testApp.routes().ServeHTTP(w, r)
+		return w.Code
+	}
+
+	// Hosts can't set the platform's fee, not even through the listing PATCH.
+	path := fmt.Sprintf("/v1/listings/%d", listing.ID)
+	require.Equal(t, http.StatusBadRequest, patch(&host, path, `{"serviceFeePercent": 0}`))
+	require.Equal(t, http.StatusForbidden, patch(&host, path+"/service-fee", `{"serviceFeePercent": 0}`))
+
+	require.Equal(t, http.StatusUnprocessableEntity, patch(&admin, path+"/service-fee", `{"serviceFeePercent": 101}`))
+	require.Equal(t, http.StatusOK, patch(&admin, path+"/service-fee", `{"serviceFeePercent": 12}`))
+
+	updated, err := testApp.models.Listings.Get(listing.ID)
+	require.NoError(t, err)
+	require.EqualValues(t, 12, updated.ServiceFee)
+}
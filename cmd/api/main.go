@@ -7,21 +7,25 @@ import (
 	"github.com/air-bnb/internal/aws"
 	"github.com/air-bnb/internal/data"
 	"github.com/air-bnb/internal/mailer"
+	"github.com/air-bnb/internal/payments"
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"net/http"
 	"os"
 	"sync"
 	"time"
 )
 
 type application struct {
-	logger *zerolog.Logger
-	wg     sync.WaitGroup
-	config config.AppConfig
-	models data.Models
-	mailer mailer.Mailer
-	aws    aws.AWS
+	logger         *zerolog.Logger
+	wg             sync.WaitGroup
+	config         config.AppConfig
+	models         data.Models
+	mailer         mailer.Mailer
+	aws            aws.AWS
+	payments       payments.Provider
+	calendarClient *http.Client
 }
 
 func main() {
@@ -39,12 +43,19 @@ func main() {
 	defer db.Close()
 	log.Logger.Info().Msg("Connected to database")
 
+	provider, err := payments.NewProvider(cfg.PaymentProvider)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to configure payment provider")
+	}
+
 	app := application{
-		logger: &log.Logger,
-		config: cfg,
-		models: data.NewModels(db),
-		mailer: mailer.NewMailer(cfg.ResendApiKey),
-		aws:    aws.NewAws(cfg.AwsAccessKey, cfg.AwsSecretKey),
+		logger:         &log.Logger,
+		config:         cfg,
+		models:         data.NewModels(db),
+		mailer:         mailer.NewMailer(cfg.ResendApiKey),
+		aws:            aws.NewAws(cfg.AwsAccessKey, cfg.AwsSecretKey),
+		payments:       provider,
+		calendarClient: newCalendarClient(),
 	}
 
 	err = app.serve()
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/air-bnb/internal/data"
+	"github.com/air-bnb/internal/payments"
+)
+
+// authorizeBooking holds the booking total with the configured provider and records the payment.
+func (app *application) authorizeBooking(ctx context.Context, booking *data.Booking, method string) (*data.Payment, error) {
+	reference, err := app.payments.Authorize(ctx, payments.AuthorizeRequest{
+		IdempotencyKey: fmt.Sprintf("booking-%d", booking.ID),
+		Amount:         booking.Total,
+		PaymentMethod:  method,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	payment := &data.Payment{
+		BookingID: booking.ID,
+		Provider:  app.payments.Name(),
+		Reference: reference,
+		Status:    data.PaymentAuthorized,
+		Amount:    booking.Total,
+	}
+
+	err = app.models.Payments.Insert(payment)
+	if err != nil {
+		// Release the hold rather than leave money on the guest's card with no record of it.
+		if voidErr := app.payments.Void(ctx, reference); voidErr != nil {
+			app.logger.Error().Err(voidErr).Str("reference", reference).Msg("failed to void payment")
+		}
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+// settleBooking brings the booking's payment in line with its new status: confirming captures the
+// hold, declining or expiring releases it, and cancelling releases it or refunds the booking's
+// RefundAmount.
+// Bookings made before payments were introduced have nothing to settle.
+func (app *application) settleBooking(ctx context.Context, booking *data.Booking) error {
+	payment, err := app.models.Payments.GetForBooking(booking.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			return nil
+		default:
+			return err
+		}
+	}
+
+	switch {
+	case booking.Status == data.BookingConfirmed && payment.Status == data.PaymentAuthorized:
+		err = app.payments.Capture(ctx, payment.Reference, payment.Amount)
+		if err != nil {
+			return err
+		}
+		payment.Status = data.PaymentCaptured
+		payment.Captured = payment.Amount
+
+	case booking.Status == data.BookingDeclined || booking.Status == data.BookingCancelled ||
+		booking.Status == data.BookingExpired:
+		switch payment.Status {
+		case data.PaymentAuthorized:
+			err = app.payments.Void(ctx, payment.Reference)
+			if err != nil {
+				return err
+			}
+			payment.Status = data.PaymentVoided
+
+		case data.PaymentCaptured:
+			refund := data.RefundDue(booking, payment)
+			if refund == 0 {
+				return nil
+			}
+			err = app.payments.Refund(ctx, payment.Reference, refund)
+			if err != nil {
+				return err
+			}
+			payment.Status = data.PaymentRefunded
+			payment.Refunded += refund
+
+		default:
+			return nil
+		}
+
+	default:
+		return nil
+	}
+
+	return app.models.Payments.Update(payment)
+}
+
+// settleBookingChange moves the money for a change's price difference so that the booking's payment
+// covers the new total. A hold that isn't captured yet is cut down to the new total, which releases
+// the rest when it is captured, or replaced by a larger one. Captured money is refunded in part, or,
+// since a booking has a single payment, the new total is charged and the old charge refunded.
+func (app *application) settleBookingChange(ctx context.Context, booking *data.Booking, change *data.BookingChange) error {
+	payment, err := app.models.Payments.GetForBooking(booking.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			return nil
+		default:
+			return err
+		}
+	}
+
+	difference := change.PriceDifference()
+	authorized, captured := payment.Status == data.PaymentAuthorized, payment.Status == data.PaymentCaptured
+
+	switch {
+	case difference == 0 || !authorized && !captured:
+		return nil
+
+	case difference < 0 && authorized:
+		payment.Amount = change.ToTotal
+
+	case difference < 0 && captured:
+		err = app.payments.Refund(ctx, payment.Reference, -difference)
+		if err != nil {
+			return err
+		}
+		payment.Refunded += -difference
+
+	default:
+		var reference string
+		reference, err = app.payments.Authorize(ctx, payments.AuthorizeRequest{
+			IdempotencyKey: fmt.Sprintf("booking-%d-change-%d", booking.ID, change.ID),
+			Amount:         change.ToTotal,
+			PaymentMethod:  change.PaymentMethod,
+		})
+		if err != nil {
+			return err
+		}
+
+		if captured {
+			err = app.payments.Capture(ctx, reference, change.ToTotal)
+			if err != nil {
+				if voidErr := app.payments.Void(ctx, reference); voidErr != nil {
+					app.logger.Error().Err(voidErr).Str("reference", reference).Msg("failed to void payment")
+				}
+				return err
+			}
+		}
+
+		// The old payment is only released once the new one is in place, and the new one is given
+		// back if that fails.
+		if captured {
+			if remaining := payment.Captured - payment.Refunded; remaining > 0 {
+				err = app.payments.Refund(ctx, payment.Reference, remaining)
+			}
+		} else {
+			err = app.payments.Void(ctx, payment.Reference)
+		}
+		if err != nil {
+			var releaseErr error
+			if captured {
+				releaseErr = app.payments.Refund(ctx, reference, change.ToTotal)
+			} else {
+				releaseErr = app.payments.Void(ctx, reference)
+			}
+			if releaseErr != nil {
+				app.logger.Error().Err(releaseErr).Str("reference", reference).Msg("failed to release payment")
+			}
+			return err
+		}
+
+		payment.Reference = reference
+		payment.Amount = change.ToTotal
+		if captured {
+			payment.Captured, payment.Refunded = change.ToTotal, 0
+		}
+	}
+
+	return app.models.Payments.Update(payment)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"github.com/air-bnb/internal/data"
+	"github.com/air-bnb/internal/payments"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSettleBookingChange(t *testing.T) {
+	ctx := context.Background()
+	owner := createTestUser(t, false)
+	guest := createTestUser(t, false)
+	listing := createTestListing(t, owner)
+
+	change := func(booking *data.Booking, total int64, method string) *data.BookingChange {
+		return &data.BookingChange{ID: booking.ID, FromTotal: booking.Total, ToTotal: total, PaymentMethod: method}
+	}
+
+	// A hold that isn't captured yet is replaced by a larger one or cut down to the new total.
+	held := createTestBooking(t, guest, listing)
+	original, err := testApp.authorizeBooking(ctx, held, "tok_visa")
+	require.NoError(t, err)
+
+	require.ErrorIs(t, testApp.settleBookingChange(ctx, held, change(held, held.Total+500, payments.FakeDeclinedMethod)), payments.ErrDeclined)
+	require.NoError(t, testApp.settleBookingChange(ctx, held, change(held, held.Total+500, "tok_visa")))
+
+	payment, err := testApp.models.Payments.GetForBooking(held.ID)
+	require.NoError(t, err)
+	require.NotEqual(t, original.Reference, payment.Reference)
+	require.Equal(t, held.Total+500, payment.Amount)
+	require.ErrorIs(t, testApp.payments.Capture(ctx, original.Reference, 1), payments.ErrInvalidState)
+
+	held.Total += 500
+	require.NoError(t, testApp.settleBookingChange(ctx, held, change(held, held.Total-200, "")))
+	payment, err = testApp.models.Payments.GetForBooking(held.ID)
+	require.NoError(t, err)
+	require.Equal(t, data.PaymentAuthorized, payment.Status)
+	require.Equal(t, held.Total-200, payment.Amount)
+
+	// Captured money is refunded in part when the stay gets cheaper.
+	paid := createTestBooking(t, guest, listing)
+	_, err = testApp.authorizeBooking(ctx, paid, "tok_visa")
+	require.NoError(t, err)
+	paid.Status = data.BookingConfirmed
+	require.NoError(t, testApp.settleBooking(ctx, paid))
+
+	require.NoError(t, testApp.settleBookingChange(ctx, paid, change(paid, paid.Total/2, "")))
+	payment, err = testApp.models.Payments.GetForBooking(paid.ID)
+	require.NoError(t, err)
+	require.Equal(t, data.PaymentCaptured, payment.Status)
+	require.Equal(t, paid.Total-paid.Total/2, payment.Refunded)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"errors"
+	"github.com/air-bnb/internal/data"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"strconv"
+)
+
+// The helpers below decide who may see and act on bookings. Handlers apply them the same way:
+//
+//   - a booking the user may not read answers 404, so that booking IDs cannot be probed;
+//   - a booking the user may read but not act on answers 403;
+//   - published listings are public, so acting on one the user doesn't own answers 403;
+//   - any other listing is hidden like a booking from everyone but its hosts and admins;
+//   - hosts booking or holding dates on their own listing answer 403.
+
+// isListingOwner reports whether user owns the listing.
+func (app *application) isListingOwner(user *data.User, listing *data.Listing) bool {
+	return listing.OwnerID == user.ID
+}
+
+// isListingHost reports whether user owns or co-hosts the listing.
+func (app *application) isListingHost(user *data.User, listing *data.Listing) (bool, error) {
+	if app.isListingOwner(user, listing) {
+		return true, nil
+	}
+	return app.models.ListingHosts.IsCoHost(listing.ID, user.ID)
+}
+
+// canReadListing lets anyone read a published listing, and only its hosts and admins read the rest.
+func (app *application) canReadListing(user *data.User, listing *data.Listing) (bool, error) {
+	if listing.Status == data.ListingPublished || user.IsAdmin {
+		return true, nil
+	}
+	return app.isListingHost(user, listing)
+}
+
+// canBookListing lets anyone but the listing's hosts book or hold its dates. Hosts close dates with
+// blocked dates instead.
+func (app *application) canBookListing(user *data.User, listing *data.Listing) (bool, error) {
+	host, err := app.isListingHost(user, listing)
+	if err != nil {
+		return false, err
+	}
+	return !host, nil
+}
+
+// canReadBooking lets the booking's guest, the listing's hosts and admins read a booking.
+func (app *application) canReadBooking(user *data.User, booking *data.Booking) (bool, error) {
+	if user.IsAdmin || booking.GuestID == user.ID {
+		return true, nil
+	}
+	return app.isListingHost(user, &booking.Listing)
+}
+
+// canReadListingBookings lets only the listing's owner see every booking made on it.
+func (app *application) canReadListingBookings(user *data.User, listing *data.Listing) bool {
+	return app.isListingOwner(user, listing)
+}
+
+// readableBooking loads the booking named by the id URL parameter and checks canReadBooking for the
+// session user. On failure it has already written the error response and returns false.
+func (app *application) readableBooking(w http.ResponseWriter, r *http.Request) (*data.Booking, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return nil, false
+	}
+
+	booking, err := app.models.Bookings.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return nil, false
+	}
+
+	ok, err := app.canReadBooking(app.contextGetUser(r), booking)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return nil, false
+	}
+	if !ok {
+		app.notFoundResponse(w, r)
+		return nil, false
+	}
+
+	return booking, true
+}
+
+// readableListing loads the listing named by the listingId URL parameter and checks canReadListing
+// for the session user. On failure it has already written the error response and returns false.
+func (app *application) readableListing(w http.ResponseWriter, r *http.Request) (*data.Listing, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "listingId"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return nil, false
+	}
+
+	listing, err := app.models.Listings.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return nil, false
+	}
+
+	ok, err := app.canReadListing(app.contextGetUser(r), listing)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return nil, false
+	}
+	if !ok {
+		app.notFoundResponse(w, r)
+		return nil, false
+	}
+
+	return listing, true
+}
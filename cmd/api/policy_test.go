@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+func TestGetBookingHandler_Authorization(t *testing.T) {
+	owner := createTestUser(t, false)
+	guest := createTestUser(t, false)
+	cohost := createTestUser(t, false)
+	stranger := createTestUser(t, false)
+	admin := createTestUser(t, true)
+
+	listing := createTestListing(t, owner)
+	require.NoError(t, testApp.models.ListingHosts.Insert(listing.ID, cohost.ID))
+	booking := createTestBooking(t, guest, listing)
+	path := fmt.Sprintf("/v1/bookings/%d", booking.ID)
+
+	tests := []struct {
+		name string
+		user *testUser
+		want int
+	}{
+		{"guest", &guest, http.StatusOK},
+		{"owner", &owner, http.StatusOK},
+		{"co-host", &cohost, http.StatusOK},
+		{"admin", &admin, http.StatusOK},
+		{"stranger", &stranger, http.StatusNotFound},
+		{"anonymous", nil, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, serve(t, http.MethodGet, path, tt.user).Code)
+		})
+	}
+}
+
+func TestGetPropertyBookingsHandler_Authorization(t *testing.T) {
+	owner := createTestUser(t, false)
+	guest := createTestUser(t, false)
+	cohost := createTestUser(t, false)
+	stranger := createTestUser(t, false)
+
+	listing := createTestListing(t, owner)
+	require.NoError(t, testApp.models.ListingHosts.Insert(listing.ID, cohost.ID))
+	createTestBooking(t, guest, listing)
+	path := fmt.Sprintf("/v1/bookings/property-bookings/%d", listing.ID)
+
+	tests := []struct {
+		name string
+		path string
+		user *testUser
+		want int
+	}{
+		{"owner", path, &owner, http.StatusOK},
+		{"co-host", path, &cohost, http.StatusForbidden},
+		{"guest", path, &guest, http.StatusForbidden},
+		{"stranger", path, &stranger, http.StatusForbidden},
+		{"missing listing", "/v1/bookings/property-bookings/999999999", &owner, http.StatusNotFound},
+		{"anonymous", path, nil, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, serve(t, http.MethodGet, tt.path, tt.user).Code)
+		})
+	}
+}
+
+func TestTransitionBooking_Authorization(t *testing.T) {
+	owner := createTestUser(t, false)
+	guest := createTestUser(t, false)
+	cohost := createTestUser(t, false)
+	stranger := createTestUser(t, false)
+
+	listing := createTestListing(t, owner)
+	require.NoError(t, testApp.models.ListingHosts.Insert(listing.ID, cohost.ID))
+
+	booking := createTestBooking(t, guest, listing)
+	path := fmt.Sprintf("/v1/bookings/%d/accept", booking.ID)
+
+	require.Equal(t, http.StatusNotFound, serve(t, http.MethodPatch, path, &stranger).Code)
+	require.Equal(t, http.StatusForbidden, serve(t, http.MethodPatch, path, &guest).Code)
+	require.Equal(t, http.StatusOK, serve(t, http.MethodPatch, path, &cohost).Code)
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"errors"
+	"github.com/air-bnb/internal/data"
+	"github.com/air-bnb/internal/validator"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"strconv"
+)
+
+func (app *application) getPricingRulesHandler(w http.ResponseWriter, r *http.Request) {
+	listing, ok := app.ownedListing(w, r)
+	if !ok {
+		return
+	}
+
+	rules, err := app.models.PricingRules.GetForListing(listing.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"pricingRules": rules}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) createPricingRuleHandler(w http.ResponseWriter, r *http.Request) {
+	listing, ok := app.ownedListing(w, r)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Kind      string  `json:"kind"`
+		StartDate string  `json:"startDate"`
+		EndDate   string  `json:"endDate"`
+		Weekdays  []int64 `json:"weekdays"`
+		Price     int64   `json:"price"`
+		Percent   int64   `json:"percent"`
+		MinNights int64   `json:"minNights"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	rule := &data.PricingRule{
+		ListingID: listing.ID,
+		Kind:      input.Kind,
+		Weekdays:  input.Weekdays,
+		Price:     input.Price,
+		Percent:   input.Percent,
+		MinNights: input.MinNights,
+	}
+	if input.StartDate != "" {
+		startDate := app.parseDate(input.StartDate, "startDate", v)
+		rule.StartDate = &startDate
+	}
+	if input.EndDate != "" {
+		endDate := app.parseDate(input.EndDate, "endDate", v)
+		rule.EndDate = &endDate
+	}
+
+	if data.ValidatePricingRule(v, rule); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.PricingRules.Insert(rule)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"pricingRule": rule}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deletePricingRuleHandler(w http.ResponseWriter, r *http.Request) {
+	listing, ok := app.ownedListing(w, r)
+	if !ok {
+		return
+	}
+
+	params := chi.URLParam(r, "ruleId")
+	id, err := strconv.ParseInt(params, 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.PricingRules.Delete(id, listing.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "pricing rule deleted successfully"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
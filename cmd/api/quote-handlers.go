@@ -0,0 +1,49 @@
+package main
+
+import (
+	"errors"
+	"github.com/air-bnb/internal/data"
+	"github.com/air-bnb/internal/validator"
+	"net/http"
+)
+
+func (app *application) getQuoteHandler(w http.ResponseWriter, r *http.Request) {
+	listing, ok := app.readableListing(w, r)
+	if !ok {
+		return
+	}
+
+	v := validator.New()
+	qs := r.URL.Query()
+
+	req := data.QuoteRequest{
+		ListingID: listing.ID,
+		CheckIn:   app.readDate(qs, "checkIn", v),
+		CheckOut:  app.readDate(qs, "checkOut", v),
+		Guests:    int64(app.readInt(qs, "guests", 1, v)),
+	}
+
+	if data.ValidateQuoteRequest(v, req); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	quote, err := app.models.Quotes.Get(req)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		case errors.Is(err, data.ErrGuestCapacity):
+			v.AddError("guests", "must not exceed the listing's guest capacity")
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"quote": quote}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
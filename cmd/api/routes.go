@@ -42,6 +42,39 @@ func (app *application) routes() *chi.Mux {
 	r.Route("/v1/listings", func(r chi.Router) {
 		r.Get("/user-listings", app.requireActivatedUser(app.getAllUserListingsHandler))
 		r.Get("/{listingId}", app.getListingHandler)
+		r.Get("/{listingId}/quote", app.getQuoteHandler)
+		r.Get("/{listingId}/availability", app.getAvailabilityHandler)
+		r.Get("/{listingId}/calendar.ics", app.exportCalendarHandler)
+		r.Post("/{listingId}/calendar-token", app.requireActivatedUser(app.rotateCalendarTokenHandler))
+		r.Get("/{listingId}/calendar-imports", app.requireActivatedUser(app.getCalendarImportsHandler))
+		r.Post("/{listingId}/calendar-imports", app.requireActivatedUser(app.createCalendarImportHandler))
+		r.Post("/{listingId}/calendar-imports/{importId}/sync", app.requireActivatedUser(app.syncCalendarImportHandler))
+		r.Delete("/{listingId}/calendar-imports/{importId}", app.requireActivatedUser(app.deleteCalendarImportHandler))
+		r.Get("/{listingId}/completeness", app.requireActivatedUser(app.getListingCompletenessHandler))
+		r.Patch("/{listingId}/publish", app.requireActivatedUser(app.publishListingHandler))
+		r.Patch("/{listingId}/unlist", app.requireActivatedUser(app.unlistListingHandler))
+		r.Patch("/{listingId}/archive", app.requireActivatedUser(app.archiveListingHandler))
+		r.Patch("/{listingId}/restore", app.requireActivatedUser(app.restoreListingHandler))
+		r.Patch("/{listingId}/approve", app.requireAdmin(app.approveListingHandler))
+		r.Patch("/{listingId}/reject", app.requireAdmin(app.rejectListingHandler))
+		r.Patch("/{listingId}/service-fee", app.requireAdmin(app.updateListingServiceFeeHandler))
+		r.Post("/{listingId}/holds", app.requireActivatedUser(app.createHoldHandler))
+		r.Delete("/{listingId}/holds/{holdId}", app.requireActivatedUser(app.deleteHoldHandler))
+		r.Route("/{listingId}/rules", func(r chi.Router) {
+			r.Get("/", app.requireActivatedUser(app.getStayRulesHandler))
+			r.Patch("/", app.requireActivatedUser(app.updateStayRulesHandler))
+			r.Get("/blocked-dates", app.requireActivatedUser(app.getBlockedDatesHandler))
+			r.Post("/blocked-dates", app.requireActivatedUser(app.createBlockedDateHandler))
+			r.Delete("/blocked-dates/{blockId}", app.requireActivatedUser(app.deleteBlockedDateHandler))
+		})
+		r.Get("/{listingId}/co-hosts", app.requireActivatedUser(app.getCoHostsHandler))
+		r.Post("/{listingId}/co-hosts", app.requireActivatedUser(app.addCoHostHandler))
+		r.Delete("/{listingId}/co-hosts/{userId}", app.requireActivatedUser(app.removeCoHostHandler))
+		r.Get("/{listingId}/cancellation-policy", app.getCancellationPolicyHandler)
+		r.Patch("/{listingId}/cancellation-policy", app.requireActivatedUser(app.updateCancellationPolicyHandler))
+		r.Get("/{listingId}/pricing-rules", app.requireActivatedUser(app.getPricingRulesHandler))
+		r.Post("/{listingId}/pricing-rules", app.requireActivatedUser(app.createPricingRuleHandler))
+		r.Delete("/{listingId}/pricing-rules/{ruleId}", app.requireActivatedUser(app.deletePricingRuleHandler))
 		r.Get("/", app.getAllListingsHandler)
 		r.Post("/", app.requireActivatedUser(app.createListingHandler))
 		r.Patch("/{listingId}", app.requireActivatedUser(app.updateListingHandler))
@@ -54,11 +87,38 @@ func (app *application) routes() *chi.Mux {
 	r.Route("/v1/bookings", func(r chi.Router) {
 		r.Post("/", app.requireActivatedUser(app.createBookingHandler))
 		r.Get("/{id}", app.requireActivatedUser(app.getBookingHandler))
-		r.Delete("/{id}", app.requireActivatedUser(app.deleteBookingHandler))
+		r.Patch("/{id}", app.requireActivatedUser(app.updateBookingHandler))
+		r.Get("/{id}/changes", app.requireActivatedUser(app.getBookingChangesHandler))
+		r.Patch("/{id}/changes/{changeId}/approve", app.requireActivatedUser(app.approveBookingChangeHandler))
+		r.Patch("/{id}/changes/{changeId}/decline", app.requireActivatedUser(app.declineBookingChangeHandler))
+		r.Delete("/{id}", app.requireActivatedUser(app.cancelBookingHandler))
+		r.Patch("/{id}/accept", app.requireActivatedUser(app.acceptBookingHandler))
+		r.Patch("/{id}/decline", app.requireActivatedUser(app.declineBookingHandler))
+		r.Patch("/{id}/cancel", app.requireActivatedUser(app.cancelBookingHandler))
 		r.Get("/user-bookings", app.requireActivatedUser(app.getUserBookingsHandler))
 		r.Get("/property-bookings/{id}", app.requireActivatedUser(app.getPropertyBookingsHandler))
 	})
 
+	r.Route("/v1/amenities", func(r chi.Router) {
+		r.Get("/", app.getAmenitiesHandler)
+		r.Post("/", app.requireAdmin(app.createAmenityHandler))
+		r.Patch("/{key}", app.requireAdmin(app.updateAmenityHandler))
+		r.Delete("/{key}", app.requireAdmin(app.deleteAmenityHandler))
+	})
+
+	r.Route("/v1/categories", func(r chi.Router) {
+		r.Get("/", app.getCategoriesHandler)
+		r.Post("/", app.requireAdmin(app.createCategoryHandler))
+		r.Patch("/{slug}", app.requireAdmin(app.updateCategoryHandler))
+		r.Delete("/{slug}", app.requireAdmin(app.deleteCategoryHandler))
+	})
+
+	r.Route("/v1/tax-rules", func(r chi.Router) {
+		r.Get("/", app.requireAdmin(app.getTaxRulesHandler))
+		r.Post("/", app.requireAdmin(app.createTaxRuleHandler))
+		r.Delete("/{id}", app.requireAdmin(app.deleteTaxRuleHandler))
+	})
+
 	r.Route("/v1/upload", func(r chi.Router) {
 		r.Post("/image", app.requireAuthenticatedUser(app.uploadImageHandler))
 	})
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"errors"
+	"github.com/air-bnb/internal/data"
+	"github.com/air-bnb/internal/validator"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+func (app *application) getStayRulesHandler(w http.ResponseWriter, r *http.Request) {
+	listing, ok := app.ownedListing(w, r)
+	if !ok {
+		return
+	}
+
+	rules, err := app.models.StayRules.Get(listing.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"rules": rules}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) updateStayRulesHandler(w http.ResponseWriter, r *http.Request) {
+	listing, ok := app.ownedListing(w, r)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		MinNights         *int64  `json:"minNights"`
+		MaxNights         *int64  `json:"maxNights"`
+		CheckInWeekdays   []int64 `json:"checkInWeekdays"`
+		AdvanceNoticeDays *int64  `json:"advanceNoticeDays"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	rules, err := app.models.StayRules.Get(listing.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if input.MinNights != nil {
+		rules.MinNights = *input.MinNights
+	}
+	if input.MaxNights != nil {
+		rules.MaxNights = *input.MaxNights
+	}
+	if input.CheckInWeekdays != nil {
+		rules.CheckInWeekdays = input.CheckInWeekdays
+	}
+	if input.AdvanceNoticeDays != nil {
+		rules.AdvanceNoticeDays = *input.AdvanceNoticeDays
+	}
+
+	v := validator.New()
+	if data.ValidateStayRules(v, rules); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.StayRules.Upsert(rules)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"rules": rules}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) getBlockedDatesHandler(w http.ResponseWriter, r *http.Request) {
+	listing, ok := app.ownedListing(w, r)
+	if !ok {
+		return
+	}
+
+	v := validator.New()
+	qs := r.URL.Query()
+
+	from := app.readDate(qs, "from", v)
+	if from.IsZero() && !qs.Has("from") {
+		from = time.Now().UTC().Truncate(24 * time.Hour)
+	}
+	to := app.readDate(qs, "to", v)
+	if to.IsZero() && !qs.Has("to") {
+		to = from.AddDate(1, 0, 0)
+	}
+
+	if data.ValidateCalendarRange(v, from, to); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	blocks, err := app.models.Blocks.GetForListing(listing.ID, from, to)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"blockedDates": blocks}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) createBlockedDateHandler(w http.ResponseWriter, r *http.Request) {
+	listing, ok := app.ownedListing(w, r)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		StartDate string `json:"startDate"`
+		EndDate   string `json:"endDate"`
+		Reason    string `json:"reason"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	block := &data.BlockedDate{
+		ListingID: listing.ID,
+		StartDate: app.parseDate(input.StartDate, "startDate", v),
+		EndDate:   app.parseDate(input.EndDate, "endDate", v),
+		Reason:    input.Reason,
+	}
+
+	if data.ValidateBlockedDate(v, block); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Blocks.Insert(block)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"blockedDate": block}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteBlockedDateHandler(w http.ResponseWriter, r *http.Request) {
+	listing, ok := app.ownedListing(w, r)
+	if !ok {
+		return
+	}
+
+	params := chi.URLParam(r, "blockId")
+	id, err := strconv.ParseInt(params, 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.Blocks.Delete(id, listing.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "blocked dates removed successfully"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
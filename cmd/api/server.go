@@ -19,6 +19,10 @@ func (app *application) serve() error {
 		WriteTimeout: 10 * time.Second,
 	}
 
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	app.startWorkers(workersCtx)
+
 	shutdownError := make(chan error)
 	go func() {
 		quit := make(chan os.Signal, 1)
@@ -32,6 +36,7 @@ func (app *application) serve() error {
 			shutdownError <- err
 		}
 		app.logger.Printf("completed shutdown with signal %s", s.String())
+		stopWorkers()
 		app.wg.Wait()
 		shutdownError <- nil
 	}()
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"github.com/air-bnb/internal/data"
+	"github.com/air-bnb/internal/validator"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"strconv"
+)
+
+func (app *application) getTaxRulesHandler(w http.ResponseWriter, r *http.Request) {
+	rules, err := app.models.TaxRules.GetAll()
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"taxRules": rules}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// createTaxRuleHandler adds an occupancy tax. Leaving out the region applies it to the whole country.
+func (app *application) createTaxRuleHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		LocationFlag     string `json:"locationFlag"`
+		LocationRegion   string `json:"locationRegion"`
+		Name             string `json:"name"`
+		RateBasisPoints  int64  `json:"rateBasisPoints"`
+		PerGuestPerNight int64  `json:"perGuestPerNight"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	rule := &data.TaxRule{
+		LocationFlag:     input.LocationFlag,
+		LocationRegion:   input.LocationRegion,
+		Name:             input.Name,
+		RateBasisPoints:  input.RateBasisPoints,
+		PerGuestPerNight: input.PerGuestPerNight,
+	}
+
+	v := validator.New()
+	if data.ValidateTaxRule(v, rule); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.TaxRules.Insert(rule)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"taxRule": rule}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteTaxRuleHandler(w http.ResponseWriter, r *http.Request) {
+	params := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(params, 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.TaxRules.Delete(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "tax rule deleted successfully"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
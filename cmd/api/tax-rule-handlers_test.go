@@ -0,0 +1,19 @@
+package main
+
+import (
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+func TestTaxRuleHandlers_Authorization(t *testing.T) {
+	user := createTestUser(t, false)
+	admin := createTestUser(t, true)
+
+	require.Equal(t, http.StatusUnauthorized, serve(t, http.MethodGet, "/v1/tax-rules", nil).Code)
+	require.Equal(t, http.StatusForbidden, serve(t, http.MethodGet, "/v1/tax-rules", &user).Code)
+	require.Equal(t, http.StatusOK, serve(t, http.MethodGet, "/v1/tax-rules", &admin).Code)
+
+	require.Equal(t, http.StatusForbidden, serve(t, http.MethodDelete, "/v1/tax-rules/1", &user).Code)
+	require.Equal(t, http.StatusNotFound, serve(t, http.MethodDelete, "/v1/tax-rules/0", &admin).Code)
+}
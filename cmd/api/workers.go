@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/air-bnb/internal/data"
+	"time"
+)
+
+// startWorkers launches the periodic background jobs. They stop when ctx is cancelled
+// and are tracked by app.wg so that shutdown waits for the current run to finish.
+func (app *application) startWorkers(ctx context.Context) {
+	app.runPeriodically(ctx, "complete bookings", time.Hour, func() error {
+		completed, err := app.models.Bookings.CompletePast()
+		if err != nil {
+			return err
+		}
+		if completed > 0 {
+			app.logger.Info().Int64("bookings", completed).Msg("marked past bookings as completed")
+		}
+		return nil
+	})
+
+	app.runPeriodically(ctx, "release expired holds", time.Minute, func() error {
+		released, err := app.models.Holds.DeleteExpired()
+		if err != nil {
+			return err
+		}
+		if released > 0 {
+			app.logger.Info().Int64("holds", released).Msg("released expired checkout holds")
+		}
+		return nil
+	})
+
+	// Feeds are synced one after another so that a slow host can only delay this worker. Failures
+	// are recorded on the feed for the host to see rather than failing the run.
+	app.runPeriodically(ctx, "sync external calendars", 5*time.Minute, func() error {
+		imports, err := app.models.Imports.GetDue(time.Now().Add(-data.CalendarSyncInterval), 50)
+		if err != nil {
+			return err
+		}
+
+		for _, imp := range imports {
+			if ctx.Err() != nil {
+				return nil
+			}
+			err := app.syncCalendarImport(ctx, imp)
+			if err != nil {
+				app.logger.Warn().Err(err).Int64("import", imp.ID).Msg("failed to sync external calendar")
+			}
+		}
+		return nil
+	})
+
+	app.runPeriodically(ctx, "expire booking requests", time.Minute, func() error {
+		expired, err := app.models.Bookings.ExpirePending()
+		if err != nil {
+			return err
+		}
+
+		// A failed release is logged and left for support; the booking itself has already expired.
+		for _, booking := range expired {
+			err := app.settleBooking(ctx, booking)
+			if err != nil {
+				app.logger.Error().Err(err).Int64("booking", booking.ID).Msg("failed to release payment of expired booking")
+			}
+		}
+		if len(expired) > 0 {
+			app.logger.Info().Int("bookings", len(expired)).Msg("expired unanswered booking requests")
+		}
+		return nil
+	})
+}
+
+func (app *application) runPeriodically(ctx context.Context, name string, interval time.Duration, fn func() error) {
+	app.wg.Add(1)
+	go func() {
+		defer app.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			app.runWorker(name, fn)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (app *application) runWorker(name string, fn func() error) {
+	defer func() {
+		if err := recover(); err != nil {
+			app.logger.Error().Err(fmt.Errorf("%s", err)).Str("worker", name).Msg("worker panicked")
+		}
+	}()
+
+	err := fn()
+	if err != nil {
+		app.logger.Error().Err(err).Str("worker", name).Msg("worker failed")
+	}
+}
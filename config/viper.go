@@ -18,6 +18,7 @@ type AppConfig struct {
 	GoogleClientSecret string `mapstructure:"GOOGLE_CLIENT_SECRET"`
 	AwsAccessKey       string `mapstructure:"AWS_ACCESS_KEY"`
 	AwsSecretKey       string `mapstructure:"AWS_SECRET_KEY"`
+	PaymentProvider    string `mapstructure:"PAYMENT_PROVIDER"`
 }
 
 func LoadConfig(path string) (AppConfig, error) {
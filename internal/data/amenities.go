@@ -0,0 +1,226 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/air-bnb/internal/validator"
+	"github.com/jackc/pgx/v5/pgconn"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// MaxListingAmenities caps how many amenities a listing, or a search, may name.
+const MaxListingAmenities = 100
+
+var (
+	ErrDuplicateAmenity = errors.New("an amenity with this key already exists")
+	ErrUnknownAmenity   = errors.New("unknown amenity")
+
+	AmenityKeyRX = regexp.MustCompile(`^[a-z0-9_]{1,64}$`)
+)
+
+type AmenityModel struct {
+	DB *sql.DB
+}
+
+// Amenity is an entry in the catalogue listings pick their amenities from. Listings refer to it by
+// Key, which never changes.
+type Amenity struct {
+	Key   string `json:"key"`
+	Label string `json:"label"`
+	Icon  string `json:"icon"`
+	Group string `json:"group"`
+}
+
+func ValidateAmenity(v *validator.Validator, amenity *Amenity) {
+	v.Check(validator.Matches(amenity.Key, AmenityKeyRX), "key", "must be 1 to 64 lowercase letters, digits or underscores")
+
+	v.Check(amenity.Label != "", "label", "must be provided")
+	v.Check(len(amenity.Label) <= 255, "label", "must not be more than 255 characters long")
+
+	v.Check(len(amenity.Icon) <= 255, "icon", "must not be more than 255 characters long")
+
+	v.Check(amenity.Group != "", "group", "must be provided")
+	v.Check(len(amenity.Group) <= 255, "group", "must not be more than 255 characters long")
+}
+
+// validateAmenityKeys checks a list of amenity keys given under key. Whether they are in the
+// catalogue is only known once they are saved.
+func validateAmenityKeys(v *validator.Validator, key string, keys []string) {
+	v.Check(len(keys) <= MaxListingAmenities, key, fmt.Sprintf("must not name more than %d amenities", MaxListingAmenities))
+	v.Check(validator.Unique(keys), key, "must not contain duplicate values")
+	for _, k := range keys {
+		v.Check(validator.Matches(k, AmenityKeyRX), key, "must only contain amenity keys")
+	}
+}
+
+// GetAll returns the whole catalogue, grouped.
+func (m AmenityModel) GetAll() ([]*Amenity, error) {
+	query := `SELECT key, label, icon, group_name FROM amenities ORDER BY group_name, label`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	amenities := []*Amenity{}
+	for rows.Next() {
+		var amenity Amenity
+		err := rows.Scan(&amenity.Key, &amenity.Label, &amenity.Icon, &amenity.Group)
+		if err != nil {
+			return nil, err
+		}
+		amenities = append(amenities, &amenity)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return amenities, nil
+}
+
+func (m AmenityModel) Insert(amenity *Amenity) error {
+	query := `INSERT INTO amenities (key, label, icon, group_name) VALUES ($1, $2, $3, $4)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, query, amenity.Key, amenity.Label, amenity.Icon, amenity.Group)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		switch {
+		case errors.As(err, &pgErr) && pgErr.ConstraintName == "amenities_pkey":
+			return ErrDuplicateAmenity
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Update changes how the amenity is shown. Its key stays the same.
+func (m AmenityModel) Update(amenity *Amenity) error {
+	query := `UPDATE amenities SET label = $1, icon = $2, group_name = $3 WHERE key = $4`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, amenity.Label, amenity.Icon, amenity.Group, amenity.Key)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (m AmenityModel) Get(key string) (*Amenity, error) {
+	query := `SELECT key, label, icon, group_name FROM amenities WHERE key = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var amenity Amenity
+	err := m.DB.QueryRowContext(ctx, query, key).Scan(&amenity.Key, &amenity.Label, &amenity.Icon, &amenity.Group)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &amenity, nil
+}
+
+// Delete removes the amenity from the catalogue and from every listing that had it.
+func (m AmenityModel) Delete(key string) error {
+	query := `DELETE FROM amenities WHERE key = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, key)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// setListingAmenities replaces the listing's amenities with keys. It returns ErrUnknownAmenity if a
+// key isn't in the catalogue.
+func setListingAmenities(ctx context.Context, tx *sql.Tx, listingID int64, keys []string) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM listing_amenities WHERE listing_id = $1`, listingID)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	args := queryArgs{listingID}
+	values := make([]string, len(keys))
+	for i, key := range keys {
+		values[i] = fmt.Sprintf("($1, %s)", args.add(key))
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO listing_amenities (listing_id, amenity_key) VALUES `+strings.Join(values, ", "), args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		switch {
+		case errors.As(err, &pgErr) && pgErr.ConstraintName == "listing_amenities_amenity_key_fkey":
+			return ErrUnknownAmenity
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// amenityKeys scans the comma-separated keys listingColumns selects. Keys can't contain commas.
+type amenityKeys []string
+
+func (k *amenityKeys) Scan(src interface{}) error {
+	var s string
+	switch src := src.(type) {
+	case string:
+		s = src
+	case []byte:
+		s = string(src)
+	default:
+		return fmt.Errorf("cannot scan %T into amenity keys", src)
+	}
+
+	*k = amenityKeys{}
+	if s != "" {
+		*k = strings.Split(s, ",")
+	}
+	return nil
+}
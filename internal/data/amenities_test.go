@@ -0,0 +1,109 @@
+package data
+
+import (
+	"github.com/air-bnb/internal/random"
+	"github.com/air-bnb/internal/validator"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func CreateRandomAmenity(t *testing.T) Amenity {
+	amenity := Amenity{
+		Key:   "test_" + random.RandString(10),
+		Label: random.RandString(10),
+		Icon:  random.RandString(5),
+		Group: "Test",
+	}
+	require.NoError(t, testQueries.Amenities.Insert(&amenity))
+
+	return amenity
+}
+
+func TestValidateAmenity(t *testing.T) {
+	v := validator.New()
+	ValidateAmenity(v, &Amenity{Key: "free_parking", Label: "Free parking", Group: "Features"})
+	require.True(t, v.Valid(), v.Errors)
+
+	v = validator.New()
+	ValidateAmenity(v, &Amenity{Key: "Free Parking", Group: "Features"})
+	require.Contains(t, v.Errors, "key")
+	require.Contains(t, v.Errors, "label")
+}
+
+func TestAmenityModel_Insert_Duplicate(t *testing.T) {
+	amenity := CreateRandomAmenity(t)
+
+	err := testQueries.Amenities.Insert(&amenity)
+	require.ErrorIs(t, err, ErrDuplicateAmenity)
+}
+
+func TestAmenityModel_Update(t *testing.T) {
+	amenity := CreateRandomAmenity(t)
+	amenity.Label = "Renamed"
+	require.NoError(t, testQueries.Amenities.Update(&amenity))
+
+	fromDB, err := testQueries.Amenities.Get(amenity.Key)
+	require.NoError(t, err)
+	require.Equal(t, "Renamed", fromDB.Label)
+
+	err = testQueries.Amenities.Update(&Amenity{Key: "test_missing_" + random.RandString(8)})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestListingsModel_Amenities(t *testing.T) {
+	user := CreateRandomUser(t)
+	wifi, pool := CreateRandomAmenity(t), CreateRandomAmenity(t)
+
+	listing := CreateRandomListing(t, user)
+	require.Empty(t, listing.Amenities)
+
+	listing.Amenities = []string{wifi.Key, pool.Key}
+	require.NoError(t, testQueries.Listings.Update(&listing))
+
+	fromDB, err := testQueries.Listings.Get(listing.ID)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{wifi.Key, pool.Key}, fromDB.Amenities)
+
+	// Leaving amenities out keeps them.
+	fromDB.Amenities = nil
+	require.NoError(t, testQueries.Listings.Update(fromDB))
+	fromDB, err = testQueries.Listings.Get(listing.ID)
+	require.NoError(t, err)
+	require.Len(t, fromDB.Amenities, 2)
+
+	fromDB.Amenities = []string{wifi.Key, "test_missing_" + random.RandString(8)}
+	err = testQueries.Listings.Update(fromDB)
+	require.ErrorIs(t, err, ErrUnknownAmenity)
+
+	// Deleting an amenity takes it off listings.
+	require.NoError(t, testQueries.Amenities.Delete(pool.Key))
+	fromDB, err = testQueries.Listings.Get(listing.ID)
+	require.NoError(t, err)
+	require.Equal(t, []string{wifi.Key}, fromDB.Amenities)
+}
+
+func TestListingsModel_GetAll_Amenities(t *testing.T) {
+	user := CreateRandomUser(t)
+	region := random.RandString(8)
+	wifi, pool := CreateRandomAmenity(t), CreateRandomAmenity(t)
+
+	insert := func(amenities ...string) int64 {
+		listing := CreateRandomListing(t, user)
+		listing.Location.Region = region
+		listing.Amenities = amenities
+		require.NoError(t, testQueries.Listings.Update(&listing))
+		return listing.ID
+	}
+	both := insert(wifi.Key, pool.Key)
+	insert(wifi.Key)
+	insert()
+
+	filters := Filters{Page: 1, PageSize: 10, Sort: "id", SortSafelist: []string{"id"}}
+	filters.Region = region
+	filters.Amenities = []string{pool.Key, wifi.Key}
+
+	listings, _, err := testQueries.Listings.GetAll(ListingSearch{}, filters)
+	require.NoError(t, err)
+	require.Len(t, listings, 1)
+	require.Equal(t, both, listings[0].ID)
+}
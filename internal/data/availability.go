@@ -0,0 +1,120 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"github.com/air-bnb/internal/validator"
+	"time"
+)
+
+const (
+	DayAvailable = "available"
+	DayBooked    = "booked"
+	DayBlocked   = "blocked"
+	DayHeld      = "held"
+)
+
+// dayPrecedence decides which status wins when several spans cover the same day.
+var dayPrecedence = map[string]int{
+	DayAvailable: 0,
+	DayHeld:      1,
+	DayBlocked:   2,
+	DayBooked:    3,
+}
+
+type AvailabilityModel struct {
+	DB *sql.DB
+}
+
+type CalendarDay struct {
+	Date   string `json:"date"`
+	Status string `json:"status"`
+}
+
+// calendarSpan is a [start, end) range of days that share a status.
+type calendarSpan struct {
+	status string
+	start  time.Time
+	end    time.Time
+}
+
+func ValidateCalendarRange(v *validator.Validator, from, to time.Time) {
+	v.Check(!from.IsZero(), "from", "must be provided")
+	v.Check(!to.IsZero(), "to", "must be provided")
+	v.Check(to.After(from), "to", "must be after from")
+	v.Check(nightCount(from, to) <= 366, "to", "range must not be longer than 366 days")
+}
+
+// Get returns one entry per day in [from, to). It deliberately reads only dates so that
+// no guest information reaches the public calendar.
+func (m AvailabilityModel) Get(listingID int64, from, to time.Time) ([]CalendarDay, error) {
+	query := `SELECT 'booked', check_in, check_out FROM bookings
+			  WHERE listing_id = $1 AND status IN ('pending', 'confirmed')
+			  AND daterange(check_in, check_out, '[)') && daterange($2, $3, '[)')
+			  UNION ALL
+			  SELECT 'blocked', start_date, end_date FROM blocked_dates
+			  WHERE listing_id = $1
+			  AND daterange(start_date, end_date, '[)') && daterange($2, $3, '[)')
+			  UNION ALL
+			  SELECT 'held', check_in, check_out FROM booking_holds
+			  WHERE listing_id = $1 AND expires_at > NOW()
+			  AND daterange(check_in, check_out, '[)') && daterange($2, $3, '[)')`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, listingID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var spans []calendarSpan
+	for rows.Next() {
+		var span calendarSpan
+		err := rows.Scan(&span.status, &span.start, &span.end)
+		if err != nil {
+			return nil, err
+		}
+		spans = append(spans, span)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return buildCalendar(from, to, spans), nil
+}
+
+// buildCalendar lays spans over the days in [from, to). Spans are clipped to that range first, since
+// an imported block may run for years.
+func buildCalendar(from, to time.Time, spans []calendarSpan) []CalendarDay {
+	from, to = truncateDate(from), truncateDate(to)
+
+	statuses := make(map[time.Time]string)
+	for _, span := range spans {
+		start, end := truncateDate(span.start), truncateDate(span.end)
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+		for _, day := range nightsBetween(start, end) {
+			if dayPrecedence[span.status] > dayPrecedence[statuses[day]] {
+				statuses[day] = span.status
+			}
+		}
+	}
+
+	days := []CalendarDay{}
+	for _, day := range nightsBetween(from, to) {
+		status, ok := statuses[day]
+		if !ok {
+			status = DayAvailable
+		}
+		days = append(days, CalendarDay{Date: day.Format(DateLayout), Status: status})
+	}
+
+	return days
+}
@@ -0,0 +1,89 @@
+package data
+
+import (
+	"github.com/air-bnb/internal/validator"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestBuildCalendar(t *testing.T) {
+	from := time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)
+	spans := []calendarSpan{
+		{status: DayBooked, start: from.AddDate(0, 0, 1), end: from.AddDate(0, 0, 3)},
+		{status: DayBlocked, start: from.AddDate(0, 0, 2), end: from.AddDate(0, 0, 4)},
+		{status: DayBlocked, start: from.AddDate(0, 0, -5), end: from},
+	}
+
+	days := buildCalendar(from, from.AddDate(0, 0, 5), spans)
+	require.Len(t, days, 5)
+	require.Equal(t, CalendarDay{Date: "2024-06-01", Status: DayAvailable}, days[0])
+	require.Equal(t, DayBooked, days[1].Status)
+	require.Equal(t, DayBooked, days[2].Status)
+	require.Equal(t, DayBlocked, days[3].Status)
+	require.Equal(t, DayAvailable, days[4].Status)
+}
+
+func TestBuildCalendar_Held(t *testing.T) {
+	from := time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)
+	spans := []calendarSpan{
+		{status: DayHeld, start: from, end: from.AddDate(0, 0, 3)},
+		{status: DayBooked, start: from.AddDate(0, 0, 2), end: from.AddDate(0, 0, 4)},
+	}
+
+	days := buildCalendar(from, from.AddDate(0, 0, 4), spans)
+	require.Equal(t, DayHeld, days[0].Status)
+	require.Equal(t, DayHeld, days[1].Status)
+	require.Equal(t, DayBooked, days[2].Status)
+	require.Equal(t, DayBooked, days[3].Status)
+}
+
+func TestBuildCalendar_LongSpan(t *testing.T) {
+	from := time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)
+	spans := []calendarSpan{
+		{status: DayBlocked, start: time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC), end: time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	days := buildCalendar(from, from.AddDate(0, 0, 2), spans)
+	require.Equal(t, []CalendarDay{{Date: "2024-06-01", Status: DayBlocked}, {Date: "2024-06-02", Status: DayBlocked}}, days)
+}
+
+func TestValidateCalendarRange(t *testing.T) {
+	from := time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)
+
+	v := validator.New()
+	ValidateCalendarRange(v, from, from.AddDate(2, 0, 0))
+	require.Contains(t, v.Errors, "to")
+
+	v = validator.New()
+	ValidateCalendarRange(v, from, from.AddDate(0, 1, 0))
+	require.True(t, v.Valid())
+}
+
+func TestAvailabilityModel_Get(t *testing.T) {
+	user := CreateRandomUser(t)
+	listing := CreateRandomListing(t, user)
+	from := truncateDate(time.Now().AddDate(0, 0, 10))
+
+	booking := &Booking{
+		ListingID: listing.ID,
+		GuestID:   user.ID,
+		CheckIn:   from.AddDate(0, 0, 1),
+		CheckOut:  from.AddDate(0, 0, 3),
+		Price:     listing.Price,
+		Total:     listing.Price * 2,
+	}
+	err := testQueries.Bookings.Insert(booking)
+	require.NoError(t, err)
+	CreateBlockedDate(t, listing.ID, from.AddDate(0, 0, 4), 1)
+
+	days, err := testQueries.Availability.Get(listing.ID, from, from.AddDate(0, 0, 6))
+	require.NoError(t, err)
+	require.Len(t, days, 6)
+	require.Equal(t, DayAvailable, days[0].Status)
+	require.Equal(t, DayBooked, days[1].Status)
+	require.Equal(t, DayBooked, days[2].Status)
+	require.Equal(t, DayAvailable, days[3].Status)
+	require.Equal(t, DayBlocked, days[4].Status)
+	require.Equal(t, DayAvailable, days[5].Status)
+}
@@ -0,0 +1,53 @@
+package data
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func CreateBlockedDate(t *testing.T, listingID int64, start time.Time, nights int) BlockedDate {
+	block := BlockedDate{
+		ListingID: listingID,
+		StartDate: start,
+		EndDate:   start.AddDate(0, 0, nights),
+		Reason:    "maintenance",
+	}
+
+	err := testQueries.Blocks.Insert(&block)
+	require.NoError(t, err)
+	require.NotZero(t, block.ID)
+
+	return block
+}
+
+func TestBlockedDateModel_Insert(t *testing.T) {
+	user := CreateRandomUser(t)
+	listing := CreateRandomListing(t, user)
+	CreateBlockedDate(t, listing.ID, time.Now().AddDate(0, 0, 5), 3)
+}
+
+func TestBlockedDateModel_GetForListing(t *testing.T) {
+	user := CreateRandomUser(t)
+	listing := CreateRandomListing(t, user)
+	start := truncateDate(time.Now().AddDate(0, 0, 5))
+	CreateBlockedDate(t, listing.ID, start, 3)
+	CreateBlockedDate(t, listing.ID, start.AddDate(0, 0, 30), 3)
+
+	blocks, err := testQueries.Blocks.GetForListing(listing.ID, start, start.AddDate(0, 0, 10))
+	require.NoError(t, err)
+	require.Len(t, blocks, 1)
+	require.Equal(t, start.Format(DateLayout), blocks[0].StartDate.Format(DateLayout))
+}
+
+func TestBlockedDateModel_Delete(t *testing.T) {
+	user := CreateRandomUser(t)
+	listing := CreateRandomListing(t, user)
+	block := CreateBlockedDate(t, listing.ID, time.Now().AddDate(0, 0, 5), 3)
+
+	err := testQueries.Blocks.Delete(block.ID, listing.ID+1)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	err = testQueries.Blocks.Delete(block.ID, listing.ID)
+	require.NoError(t, err)
+}
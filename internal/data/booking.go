@@ -4,53 +4,344 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"github.com/air-bnb/internal/validator"
+	"github.com/jackc/pgx/v5/pgconn"
+	"strings"
 	"time"
 )
 
+const (
+	BookingPending   = "pending"
+	BookingConfirmed = "confirmed"
+	BookingDeclined  = "declined"
+	BookingCancelled = "cancelled"
+	BookingCompleted = "completed"
+	BookingExpired   = "expired"
+)
+
+var BookingStatuses = []string{
+	BookingPending,
+	BookingConfirmed,
+	BookingDeclined,
+	BookingCancelled,
+	BookingCompleted,
+	BookingExpired,
+}
+
+// bookingTransitions lists the statuses each status may move to. Anything missing is final.
+var bookingTransitions = map[string][]string{
+	BookingPending:   {BookingConfirmed, BookingDeclined, BookingCancelled, BookingExpired},
+	BookingConfirmed: {BookingCancelled, BookingCompleted},
+}
+
+var (
+	ErrBookingConflict   = errors.New("booking conflict")
+	ErrInvalidTransition = errors.New("invalid booking status transition")
+	ErrEditConflict      = errors.New("edit conflict")
+	ErrRequestExpired    = errors.New("booking request has expired")
+)
+
+// BookingConflictError is returned when a booking overlaps an existing stay on the same listing.
+type BookingConflictError struct {
+	CheckIn  time.Time
+	CheckOut time.Time
+}
+
+func (e *BookingConflictError) Error() string {
+	return fmt.Sprintf("listing is already booked from %s to %s",
+		e.CheckIn.Format(DateLayout), e.CheckOut.Format(DateLayout))
+}
+
+func (e *BookingConflictError) Unwrap() error {
+	return ErrBookingConflict
+}
+
+// MaxInfants is how many infants may come along on any booking. Infants don't count towards a
+// listing's guest capacity.
+const MaxInfants = 5
+
 type BookingModel struct {
 	DB *sql.DB
 }
 
 type Booking struct {
-	ID        int64     `json:"id"`
-	CreatedAt string    `json:"createdAt"`
-	ListingID int64     `json:"listingId"`
-	GuestID   int64     `json:"guestId"`
-	CheckIn   time.Time `json:"checkIn"`
-	CheckOut  time.Time `json:"checkOut"`
-	Price     int64     `json:"price"`
-	Total     int64     `json:"total"`
-	Listing   Listing   `json:"listing"`
-}
-
-func ValidateBooking(validator *validator.Validator, booking *Booking) {
+	ID           int64       `json:"id"`
+	CreatedAt    string      `json:"createdAt"`
+	ListingID    int64       `json:"listingId"`
+	GuestID      int64       `json:"guestId"`
+	CheckIn      time.Time   `json:"checkIn"`
+	CheckOut     time.Time   `json:"checkOut"`
+	Guests       int64       `json:"guests"`
+	Adults       int64       `json:"adults"`
+	Children     int64       `json:"children"`
+	Infants      int64       `json:"infants"`
+	Pets         int64       `json:"pets"`
+	Price        int64       `json:"price"`
+	Total        int64       `json:"total"`
+	Status       string      `json:"status"`
+	ConfirmedAt  *time.Time  `json:"confirmedAt,omitempty"`
+	DeclinedAt   *time.Time  `json:"declinedAt,omitempty"`
+	CancelledAt  *time.Time  `json:"cancelledAt,omitempty"`
+	CompletedAt  *time.Time  `json:"completedAt,omitempty"`
+	ExpiresAt    *time.Time  `json:"expiresAt,omitempty"`
+	ExpiredAt    *time.Time  `json:"expiredAt,omitempty"`
+	RefundAmount int64       `json:"refundAmount"`
+	Listing      Listing     `json:"listing"`
+	LineItems    []*LineItem `json:"lineItems,omitempty"`
+
+	// CancellationPolicy is the listing's policy as it was when the booking was made. Refunds
+	// follow it even if the host changes the listing's policy later.
+	CancellationPolicy CancellationPolicy `json:"cancellationPolicy"`
+}
+
+// bookingColumns matches the destinations returned by Booking.scanDest.
+const bookingColumns = `b.id, b.created_at, b.listing_id, b.guest_id, b.check_in, b.check_out, b.guests,
+			  b.adults, b.children, b.infants, b.pets, b.price, b.total, b.status, b.confirmed_at, b.declined_at, b.cancelled_at, b.completed_at,
+			  b.expires_at, b.expired_at, b.refund_amount, b.listing_id, b.cancellation_policy,
+			  array_to_string(ARRAY(SELECT t.days || ':' || t.percent
+			  FROM unnest(b.cancellation_tier_days, b.cancellation_tier_refund_percents) AS t(days, percent)), ','),
+			  ` + listingColumns
+
+func (b *Booking) scanDest() []interface{} {
+	dest := []interface{}{
+		&b.ID,
+		&b.CreatedAt,
+		&b.ListingID,
+		&b.GuestID,
+		&b.CheckIn,
+		&b.CheckOut,
+		&b.Guests,
+		&b.Adults,
+		&b.Children,
+		&b.Infants,
+		&b.Pets,
+		&b.Price,
+		&b.Total,
+		&b.Status,
+		&b.ConfirmedAt,
+		&b.DeclinedAt,
+		&b.CancelledAt,
+		&b.CompletedAt,
+		&b.ExpiresAt,
+		&b.ExpiredAt,
+		&b.RefundAmount,
+		&b.CancellationPolicy.ListingID,
+		&b.CancellationPolicy.Name,
+		(*cancellationTiers)(&b.CancellationPolicy.Tiers),
+	}
+	return append(dest, b.Listing.scanDest()...)
+}
+
+// ValidateBooking checks the booking against the listing it is for. Adults and children count
+// towards the listing's capacity; infants and pets have limits of their own.
+func ValidateBooking(validator *validator.Validator, booking *Booking, listing *Listing) {
+	validator.Check(!booking.CheckIn.IsZero(), "checkIn", "must be provided")
+	validator.Check(!booking.CheckOut.IsZero(), "checkOut", "must be provided")
+	validator.Check(booking.CheckOut.After(booking.CheckIn), "checkOut", "must be after check-in")
 	validator.Check(booking.Price > 0, "price", "must be greater than zero")
 	validator.Check(booking.Total > 0, "total", "must be greater than zero")
+
+	validator.Check(booking.Adults >= 1, "adults", "must be at least one")
+	validator.Check(booking.Children >= 0, "children", "must not be negative")
+	validator.Check(booking.Adults+booking.Children <= listing.Guests, "guests",
+		"must not exceed the listing's guest capacity")
+
+	validator.Check(booking.Infants >= 0, "infants", "must not be negative")
+	validator.Check(booking.Infants <= MaxInfants, "infants", fmt.Sprintf("must not be more than %d", MaxInfants))
+
+	validator.Check(booking.Pets >= 0, "pets", "must not be negative")
+	if listing.MaxPets == 0 {
+		validator.Check(booking.Pets == 0, "pets", "this listing does not allow pets")
+	} else {
+		validator.Check(booking.Pets <= listing.MaxPets, "pets", fmt.Sprintf("must not be more than %d", listing.MaxPets))
+	}
+}
+
+func ValidateBookingStatusFilter(v *validator.Validator, status string) {
+	if status != "" {
+		v.Check(validator.PermittedValue(status, BookingStatuses...), "status", "invalid status value")
+	}
+}
+
+func (b *Booking) CanTransition(status string) bool {
+	return validator.PermittedValue(status, bookingTransitions[b.Status]...)
+}
+
+// Transition moves the booking to status and stamps the matching timestamp.
+// It only changes the struct; persist it with BookingModel.UpdateStatus.
+// A request can no longer be confirmed once its expiry has passed, even if
+// BookingModel.ExpirePending has not caught up with it yet.
+func (b *Booking) Transition(status string) error {
+	if !b.CanTransition(status) {
+		return ErrInvalidTransition
+	}
+
+	now := time.Now()
+	if status == BookingConfirmed && b.ExpiresAt != nil && now.After(*b.ExpiresAt) {
+		return ErrRequestExpired
+	}
+
+	switch status {
+	case BookingConfirmed:
+		b.ConfirmedAt = &now
+	case BookingDeclined:
+		b.DeclinedAt = &now
+	case BookingCancelled:
+		b.CancelledAt = &now
+	case BookingCompleted:
+		b.CompletedAt = &now
+	case BookingExpired:
+		b.ExpiredAt = &now
+	}
+	b.Status = status
+
+	return nil
 }
 
+// Insert stores the booking and its line items. It fails with ErrDatesHeld if another guest is
+// checking out the same dates and with a *BookingConflictError if they are already booked.
 func (m BookingModel) Insert(booking *Booking) error {
-	query := `INSERT INTO bookings (listing_id, guest_id, check_in, check_out, price, total)
-    	VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at`
+	if booking.Status == "" {
+		booking.Status = BookingPending
+	}
 
-	args := []interface{}{booking.ListingID, booking.GuestID, booking.CheckIn, booking.CheckOut, booking.Price, booking.Total}
+	// Callers that only know the guest count book for that many adults.
+	if booking.Adults == 0 && booking.Children == 0 {
+		booking.Adults = max(booking.Guests, 1)
+	}
+	booking.Guests = booking.Adults + booking.Children
+
+	query := `INSERT INTO bookings (listing_id, guest_id, check_in, check_out, guests, adults, children,
+			  infants, pets, price, total, status, confirmed_at, expires_at, cancellation_policy,
+			  cancellation_tier_days, cancellation_tier_refund_percents)
+    	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17) RETURNING id, created_at`
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&booking.ID, &booking.CreatedAt)
+	tx, err := m.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	return nil
+	err = lockListingDates(ctx, tx, booking.ListingID, booking.CheckIn, booking.CheckOut, booking.GuestID)
+	if err != nil {
+		return err
+	}
+
+	// The policy is read under the listing lock and copied tiers and all, presets included, so the
+	// booking keeps the terms the guest accepted.
+	policy, err := getCancellationPolicy(ctx, tx, booking.ListingID)
+	if err != nil {
+		return err
+	}
+	booking.CancellationPolicy = *policy
+
+	days, percents := []int64{}, []int64{}
+	for _, tier := range policy.Tiers {
+		days = append(days, tier.DaysBefore)
+		percents = append(percents, tier.RefundPercent)
+	}
+
+	args := []interface{}{
+		booking.ListingID,
+		booking.GuestID,
+		booking.CheckIn,
+		booking.CheckOut,
+		booking.Guests,
+		booking.Adults,
+		booking.Children,
+		booking.Infants,
+		booking.Pets,
+		booking.Price,
+		booking.Total,
+		booking.Status,
+		booking.ConfirmedAt,
+		booking.ExpiresAt,
+		policy.Name,
+		SavePgIntArray(days),
+		SavePgIntArray(percents),
+	}
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&booking.ID, &booking.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		switch {
+		case errors.As(err, &pgErr) && pgErr.ConstraintName == "bookings_no_overlap":
+			return m.conflict(booking.ListingID, booking.CheckIn, booking.CheckOut)
+		default:
+			return err
+		}
+	}
+
+	err = insertLineItems(ctx, tx, booking.ID, booking.LineItems)
+	if err != nil {
+		return err
+	}
+
+	// The guest's checkout hold has served its purpose.
+	_, err = tx.ExecContext(ctx, `DELETE FROM booking_holds WHERE listing_id = $1 AND guest_id = $2`,
+		booking.ListingID, booking.GuestID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// conflict looks up the stay that blocked a booking so the caller can report its dates.
+func (m BookingModel) conflict(listingID int64, checkIn, checkOut time.Time) error {
+	conflict, err := m.FindConflict(listingID, checkIn, checkOut, 0)
+	if err != nil {
+		return err
+	}
+	if conflict == nil {
+		return &BookingConflictError{CheckIn: checkIn, CheckOut: checkOut}
+	}
+	return conflict
+}
+
+// FindConflict returns the first active booking other than excludeID that overlaps the given
+// dates, or nil when they are free.
+func (m BookingModel) FindConflict(listingID int64, checkIn, checkOut time.Time, excludeID int64) (*BookingConflictError, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return findConflict(ctx, m.DB, listingID, checkIn, checkOut, excludeID)
+}
+
+// rowQuerier is the part of *sql.DB and *sql.Tx that findConflict needs.
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// findConflict is FindConflict on any connection, so that writes can check inside their own
+// transaction after lockListingDates.
+func findConflict(ctx context.Context, q rowQuerier, listingID int64, checkIn, checkOut time.Time, excludeID int64) (*BookingConflictError, error) {
+	query := `SELECT check_in, check_out FROM bookings
+			  WHERE listing_id = $1 AND status IN ('pending', 'confirmed') AND id <> $4
+			  AND daterange(check_in, check_out, '[)') && daterange($2, $3, '[)')
+			  ORDER BY check_in
+			  LIMIT 1`
+
+	var conflict BookingConflictError
+	err := q.QueryRowContext(ctx, query, listingID, checkIn, checkOut, excludeID).Scan(&conflict.CheckIn, &conflict.CheckOut)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, nil
+		default:
+			return nil, err
+		}
+	}
+
+	return &conflict, nil
 }
 
 func (m BookingModel) Get(id int64) (*Booking, error) {
-	query := `SELECT b.id, b.created_at, b.listing_id, b.guest_id, b.check_in, b.check_out,
-			  b.price, b.total, l.id, l.title, l.description, l.category, l.bedrooms,
-			  l.bathrooms, l.guests, l.location_flag, l.location_label, l.location_lat, l.location_lng,	
-			  l.location_region, l.location_value, l.price, l.owner_id, u.name, COALESCE(u.image, '')
+	query := `SELECT ` + bookingColumns + `
 			  FROM bookings b
 			  INNER JOIN listings l ON l.id = b.listing_id
 			  INNER JOIN users u ON u.id = l.owner_id
@@ -61,33 +352,7 @@ func (m BookingModel) Get(id int64) (*Booking, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	err := m.DB.QueryRowContext(ctx, query, id).Scan(
-		&booking.ID,
-		&booking.CreatedAt,
-		&booking.ListingID,
-		&booking.GuestID,
-		&booking.CheckIn,
-		&booking.CheckOut,
-		&booking.Price,
-		&booking.Total,
-		&booking.Listing.ID,
-		&booking.Listing.Title,
-		&booking.Listing.Description,
-		&booking.Listing.Category,
-		&booking.Listing.Bedrooms,
-		&booking.Listing.Bathrooms,
-		&booking.Listing.Guests,
-		&booking.Listing.Location.Flag,
-		&booking.Listing.Location.Label,
-		&booking.Listing.Location.Lat,
-		&booking.Listing.Location.Lng,
-		&booking.Listing.Location.Region,
-		&booking.Listing.Location.Value,
-		&booking.Listing.Price,
-		&booking.Listing.OwnerID,
-		&booking.Listing.OwnerName,
-		&booking.Listing.OwnerPhoto,
-	)
+	err := m.DB.QueryRowContext(ctx, query, id).Scan(booking.scanDest()...)
 	if err != nil {
 		switch {
 		case errors.Is(err, sql.ErrNoRows):
@@ -100,6 +365,93 @@ func (m BookingModel) Get(id int64) (*Booking, error) {
 	return &booking, nil
 }
 
+// UpdateStatus persists a transition made with Booking.Transition. from is the status the
+// booking was read with; if another request changed it in the meantime ErrEditConflict is returned.
+func (m BookingModel) UpdateStatus(booking *Booking, from string) error {
+	query := `UPDATE bookings SET status = $1, confirmed_at = $2, declined_at = $3, cancelled_at = $4,
+			  completed_at = $5, expired_at = $6, refund_amount = $7
+			  WHERE id = $8 AND status = $9`
+
+	args := []interface{}{
+		booking.Status,
+		booking.ConfirmedAt,
+		booking.DeclinedAt,
+		booking.CancelledAt,
+		booking.CompletedAt,
+		booking.ExpiredAt,
+		booking.RefundAmount,
+		booking.ID,
+		from,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrEditConflict
+	}
+
+	return nil
+}
+
+// CompletePast marks confirmed bookings whose check-out date has passed as completed.
+func (m BookingModel) CompletePast() (int64, error) {
+	query := `UPDATE bookings SET status = 'completed', completed_at = NOW()
+			  WHERE status = 'confirmed' AND check_out <= CURRENT_DATE`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// ExpirePending expires the booking requests whose host did not answer in time, which frees their
+// dates. It returns the expired bookings so that their payments can be released.
+func (m BookingModel) ExpirePending() ([]*Booking, error) {
+	query := `UPDATE bookings SET status = 'expired', expired_at = NOW()
+			  WHERE status = 'pending' AND expires_at <= NOW()
+			  RETURNING id, listing_id, guest_id, status, expired_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bookings []*Booking
+	for rows.Next() {
+		var booking Booking
+		err := rows.Scan(&booking.ID, &booking.ListingID, &booking.GuestID, &booking.Status, &booking.ExpiredAt)
+		if err != nil {
+			return nil, err
+		}
+		bookings = append(bookings, &booking)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return bookings, nil
+}
+
 func (m BookingModel) Delete(id, guestId int64) error {
 	query := `DELETE FROM bookings WHERE id = $1 AND guest_id = $2`
 
@@ -114,122 +466,92 @@ func (m BookingModel) Delete(id, guestId int64) error {
 	return nil
 }
 
-func (m BookingModel) GetForUser(userID int64) ([]*Booking, error) {
-	query := `SELECT b.id, b.created_at, b.listing_id, b.guest_id, b.check_in, b.check_out,
-			  b.price, b.total, l.id, l.title, l.description, l.category, l.bedrooms,
-			  l.bathrooms, l.guests, l.location_flag, l.location_label, l.location_lat, l.location_lng,	
-			  l.location_region, l.location_value, l.price, l.owner_id, u.name, COALESCE(u.image, '')
+// GetForUser returns the user's bookings, optionally restricted to a single status. Without a page
+// size in filters it returns all of them.
+func (m BookingModel) GetForUser(userID int64, status string, filters Filters) ([]*Booking, Metadata, error) {
+	args := queryArgs{userID, status}
+	conditions := []string{"b.guest_id = $1", "($2 = '' OR b.status = $2)"}
+
+	key := filters.sortKey(map[string]sortKey{
+		"id":         {"b.id", "bigint"},
+		"created_at": {"b.created_at", "timestamp"},
+		"check_in":   {"b.check_in", "date"},
+	})
+	after, orderBy, limit := filters.pageSQL(&args, key, "b.id")
+	if after != "" {
+		conditions = append(conditions, after)
+	}
+
+	count := "count(*) OVER()"
+	if filters.Cursor != nil {
+		count = "0"
+	}
+
+	query := `SELECT ` + count + `, ` + bookingColumns + `, (` + key.expr + `)::text
 			  FROM bookings b
 			  INNER JOIN listings l ON l.id = b.listing_id
 			  INNER JOIN users u ON u.id = l.owner_id
-			  WHERE b.guest_id = $1
-			  ORDER BY b.created_at DESC`
+			  WHERE ` + strings.Join(conditions, ` AND `) + `
+			  ORDER BY ` + orderBy + ` ` + limit
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	rows, err := m.DB.QueryContext(ctx, query, userID)
-	defer rows.Close()
+	rows, err := m.DB.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, Metadata{}, cursorError(filters, err)
 	}
+	defer rows.Close()
 
-	var bookings []*Booking
+	totalRecords := 0
+	var read []pageRow[*Booking]
 
 	for rows.Next() {
 		var booking Booking
-		err := rows.Scan(
-			&booking.ID,
-			&booking.CreatedAt,
-			&booking.ListingID,
-			&booking.GuestID,
-			&booking.CheckIn,
-			&booking.CheckOut,
-			&booking.Price,
-			&booking.Total,
-			&booking.Listing.ID,
-			&booking.Listing.Title,
-			&booking.Listing.Description,
-			&booking.Listing.Category,
-			&booking.Listing.Bedrooms,
-			&booking.Listing.Bathrooms,
-			&booking.Listing.Guests,
-			&booking.Listing.Location.Flag,
-			&booking.Listing.Location.Label,
-			&booking.Listing.Location.Lat,
-			&booking.Listing.Location.Lng,
-			&booking.Listing.Location.Region,
-			&booking.Listing.Location.Value,
-			&booking.Listing.Price,
-			&booking.Listing.OwnerID,
-			&booking.Listing.OwnerName,
-			&booking.Listing.OwnerPhoto,
-		)
+		var sortKey string
+
+		dest := append([]interface{}{&totalRecords}, booking.scanDest()...)
+		err := rows.Scan(append(dest, &sortKey)...)
 		if err != nil {
-			return nil, err
+			return nil, Metadata{}, err
 		}
 
-		bookings = append(bookings, &booking)
+		read = append(read, pageRow[*Booking]{row: &booking, key: sortKey, id: booking.ID})
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, err
+		return nil, Metadata{}, cursorError(filters, err)
 	}
 
-	return bookings, nil
+	bookings, metadata := page(filters, totalRecords, read)
+
+	return bookings, metadata, nil
 }
 
-func (m BookingModel) GetForListing(listingID int64) ([]*Booking, error) {
-	query := `SELECT b.id, b.created_at, b.listing_id, b.guest_id, b.check_in, b.check_out,
-			  b.price, b.total, l.id, l.title, l.description, l.category, l.bedrooms,
-			  l.bathrooms, l.guests, l.location_flag, l.location_label, l.location_lat, l.location_lng,	
-			  l.location_region, l.location_value, l.price, l.owner_id, u.name, COALESCE(u.image, '')
+// GetForListing returns the listing's bookings, optionally restricted to a single status.
+func (m BookingModel) GetForListing(listingID int64, status string) ([]*Booking, error) {
+	query := `SELECT ` + bookingColumns + `
 			  FROM bookings b
 			  INNER JOIN listings l ON l.id = b.listing_id
 			  INNER JOIN users u ON u.id = b.guest_id
 			  WHERE b.listing_id = $1
+			  AND ($2 = '' OR b.status = $2)
 			  ORDER BY b.created_at DESC`
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	rows, err := m.DB.QueryContext(ctx, query, listingID)
-	defer rows.Close()
+	rows, err := m.DB.QueryContext(ctx, query, listingID, status)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	var bookings []*Booking
 
 	for rows.Next() {
 		var booking Booking
-		err := rows.Scan(
-			&booking.ID,
-			&booking.CreatedAt,
-			&booking.ListingID,
-			&booking.GuestID,
-			&booking.CheckIn,
-			&booking.CheckOut,
-			&booking.Price,
-			&booking.Total,
-			&booking.Listing.ID,
-			&booking.Listing.Title,
-			&booking.Listing.Description,
-			&booking.Listing.Category,
-			&booking.Listing.Bedrooms,
-			&booking.Listing.Bathrooms,
-			&booking.Listing.Guests,
-			&booking.Listing.Location.Flag,
-			&booking.Listing.Location.Label,
-			&booking.Listing.Location.Lat,
-			&booking.Listing.Location.Lng,
-			&booking.Listing.Location.Region,
-			&booking.Listing.Location.Value,
-			&booking.Listing.Price,
-			&booking.Listing.OwnerID,
-			&booking.Listing.OwnerName,
-			&booking.Listing.OwnerPhoto,
-		)
+		err := rows.Scan(booking.scanDest()...)
 		if err != nil {
 			return nil, err
 		}
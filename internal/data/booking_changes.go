@@ -0,0 +1,342 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/air-bnb/internal/validator"
+	"github.com/jackc/pgx/v5/pgconn"
+	"time"
+)
+
+const (
+	ChangePending  = "pending"
+	ChangeApplied  = "applied"
+	ChangeDeclined = "declined"
+)
+
+var ErrPendingChange = errors.New("booking already has a pending change")
+
+type BookingChangeModel struct {
+	DB *sql.DB
+}
+
+// BookingChange is a guest's request to move a booking to new dates or a new guest count. Changes
+// are kept after they are applied or declined, so they also serve as the booking's history.
+type BookingChange struct {
+	ID           int64      `json:"id"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	BookingID    int64      `json:"bookingId"`
+	RequestedBy  int64      `json:"requestedBy"`
+	Status       string     `json:"status"`
+	FromCheckIn  time.Time  `json:"fromCheckIn"`
+	FromCheckOut time.Time  `json:"fromCheckOut"`
+	FromGuests   int64      `json:"fromGuests"`
+	FromTotal    int64      `json:"fromTotal"`
+	ToCheckIn    time.Time  `json:"toCheckIn"`
+	ToCheckOut   time.Time  `json:"toCheckOut"`
+	ToGuests     int64      `json:"toGuests"`
+	ToPrice      int64      `json:"toPrice"`
+	ToTotal      int64      `json:"toTotal"`
+	DecidedAt    *time.Time `json:"decidedAt,omitempty"`
+
+	// PaymentMethod is the card token that pays for a change costing more than the booking.
+	PaymentMethod string `json:"-"`
+}
+
+// NewBookingChange describes moving booking to the stay priced by quote.
+func NewBookingChange(booking *Booking, quote *Quote, requestedBy int64) *BookingChange {
+	checkIn, _ := time.Parse(DateLayout, quote.CheckIn)
+	checkOut, _ := time.Parse(DateLayout, quote.CheckOut)
+
+	return &BookingChange{
+		BookingID:    booking.ID,
+		RequestedBy:  requestedBy,
+		Status:       ChangePending,
+		FromCheckIn:  booking.CheckIn,
+		FromCheckOut: booking.CheckOut,
+		FromGuests:   booking.Guests,
+		FromTotal:    booking.Total,
+		ToCheckIn:    checkIn,
+		ToCheckOut:   checkOut,
+		ToGuests:     quote.Guests,
+		ToPrice:      quote.BasePrice,
+		ToTotal:      quote.Total,
+	}
+}
+
+// PriceDifference is what the guest owes (positive) or gets back (negative) if the change is applied.
+func (c *BookingChange) PriceDifference() int64 {
+	return c.ToTotal - c.FromTotal
+}
+
+func ValidateBookingChange(v *validator.Validator, booking *Booking, change *BookingChange) {
+	v.Check(validator.PermittedValue(booking.Status, BookingPending, BookingConfirmed), "status",
+		"only pending or confirmed bookings can be changed")
+
+	unchanged := truncateDate(change.ToCheckIn).Equal(truncateDate(change.FromCheckIn)) &&
+		truncateDate(change.ToCheckOut).Equal(truncateDate(change.FromCheckOut)) &&
+		change.ToGuests == change.FromGuests
+	v.Check(!unchanged, "booking", "must change the dates or the number of guests")
+
+	// A change only sets the guest count; the children on the booking stay and the adults make up the rest.
+	v.Check(change.ToGuests > booking.Children, "guests",
+		fmt.Sprintf("must include at least one adult besides the %d children", booking.Children))
+}
+
+const bookingChangeColumns = `id, created_at, booking_id, requested_by, status, from_check_in, from_check_out,
+			  from_guests, from_total, to_check_in, to_check_out, to_guests, to_price, to_total, decided_at,
+			  payment_method`
+
+func (c *BookingChange) scanDest() []interface{} {
+	return []interface{}{
+		&c.ID,
+		&c.CreatedAt,
+		&c.BookingID,
+		&c.RequestedBy,
+		&c.Status,
+		&c.FromCheckIn,
+		&c.FromCheckOut,
+		&c.FromGuests,
+		&c.FromTotal,
+		&c.ToCheckIn,
+		&c.ToCheckOut,
+		&c.ToGuests,
+		&c.ToPrice,
+		&c.ToTotal,
+		&c.DecidedAt,
+		&c.PaymentMethod,
+	}
+}
+
+// Insert records a pending change. Only one change per booking may be pending; a second one returns
+// ErrPendingChange.
+func (m BookingChangeModel) Insert(change *BookingChange) error {
+	query := `INSERT INTO booking_changes (booking_id, requested_by, status, from_check_in, from_check_out,
+			  from_guests, from_total, to_check_in, to_check_out, to_guests, to_price, to_total, payment_method)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) RETURNING id, created_at`
+
+	args := []interface{}{
+		change.BookingID,
+		change.RequestedBy,
+		change.Status,
+		change.FromCheckIn,
+		change.FromCheckOut,
+		change.FromGuests,
+		change.FromTotal,
+		change.ToCheckIn,
+		change.ToCheckOut,
+		change.ToGuests,
+		change.ToPrice,
+		change.ToTotal,
+		change.PaymentMethod,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&change.ID, &change.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		switch {
+		case errors.As(err, &pgErr) && pgErr.ConstraintName == "booking_changes_one_pending_idx":
+			return ErrPendingChange
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m BookingChangeModel) Get(id, bookingID int64) (*BookingChange, error) {
+	query := `SELECT ` + bookingChangeColumns + `
+			  FROM booking_changes
+			  WHERE id = $1 AND booking_id = $2`
+
+	var change BookingChange
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, id, bookingID).Scan(change.scanDest()...)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &change, nil
+}
+
+// GetForBooking returns the booking's change history, newest first.
+func (m BookingChangeModel) GetForBooking(bookingID int64) ([]*BookingChange, error) {
+	query := `SELECT ` + bookingChangeColumns + `
+			  FROM booking_changes
+			  WHERE booking_id = $1
+			  ORDER BY id DESC`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := []*BookingChange{}
+	for rows.Next() {
+		var change BookingChange
+		err := rows.Scan(change.scanDest()...)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, &change)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// Apply moves booking to the change's dates, guest count and price and, in the same transaction,
+// writes items, the breakdown of the re-quoted stay, as the booking's current line items. The
+// items are tagged with the change, so the breakdown the guest first agreed to is kept. The
+// bookings_no_overlap constraint
+// re-checks availability, so a stay taken since the change was requested returns a
+// *BookingConflictError, and dates another guest is checking out return ErrDatesHeld. If the booking
+// was changed or left the pending/confirmed statuses in the meantime ErrEditConflict is returned.
+//
+// settle moves the money for the price difference. It is called last, once the booking row is
+// locked and the change claimed, and the transaction only commits if it succeeds, so a failed
+// payment leaves the booking as it was.
+func (m BookingChangeModel) Apply(booking *Booking, change *BookingChange, items []*LineItem, settle func() error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = lockListingDates(ctx, tx, booking.ListingID, change.ToCheckIn, change.ToCheckOut, booking.GuestID)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE bookings SET check_in = $1, check_out = $2, guests = $3, adults = $3 - children,
+			  price = $4, total = $5
+			  WHERE id = $6 AND check_in = $7 AND check_out = $8 AND guests = $9
+			  AND status IN ('pending', 'confirmed')`
+
+	args := []interface{}{
+		change.ToCheckIn,
+		change.ToCheckOut,
+		change.ToGuests,
+		change.ToPrice,
+		change.ToTotal,
+		change.BookingID,
+		change.FromCheckIn,
+		change.FromCheckOut,
+		change.FromGuests,
+	}
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		switch {
+		case errors.As(err, &pgErr) && pgErr.ConstraintName == "bookings_no_overlap":
+			tx.Rollback()
+			return BookingModel{DB: m.DB}.conflict(booking.ListingID, change.ToCheckIn, change.ToCheckOut)
+		default:
+			return err
+		}
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrEditConflict
+	}
+
+	err = m.decide(ctx, tx, change, ChangeApplied)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		item.ChangeID = &change.ID
+	}
+	err = insertLineItems(ctx, tx, booking.ID, items)
+	if err != nil {
+		return err
+	}
+
+	err = settle()
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	booking.CheckIn = change.ToCheckIn
+	booking.CheckOut = change.ToCheckOut
+	booking.Guests = change.ToGuests
+	booking.Adults = change.ToGuests - booking.Children
+	booking.Price = change.ToPrice
+	booking.Total = change.ToTotal
+	booking.LineItems = items
+
+	return nil
+}
+
+// Decline marks a pending change as declined without touching the booking.
+func (m BookingChangeModel) Decline(change *BookingChange) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = m.decide(ctx, tx, change, ChangeDeclined)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m BookingChangeModel) decide(ctx context.Context, tx *sql.Tx, change *BookingChange, status string) error {
+	query := `UPDATE booking_changes SET status = $1, decided_at = NOW()
+			  WHERE id = $2 AND status = 'pending'
+			  RETURNING decided_at`
+
+	err := tx.QueryRowContext(ctx, query, status, change.ID).Scan(&change.DecidedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrEditConflict
+		default:
+			return err
+		}
+	}
+	change.Status = status
+
+	return nil
+}
@@ -0,0 +1,163 @@
+package data
+
+import (
+	"errors"
+	"github.com/air-bnb/internal/validator"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestValidateBookingChange(t *testing.T) {
+	checkIn := time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)
+	booking := &Booking{Status: BookingConfirmed, CheckIn: checkIn, CheckOut: checkIn.AddDate(0, 0, 2), Guests: 2}
+
+	unchanged := &BookingChange{
+		FromCheckIn: booking.CheckIn, FromCheckOut: booking.CheckOut, FromGuests: 2,
+		ToCheckIn: booking.CheckIn, ToCheckOut: booking.CheckOut, ToGuests: 2,
+	}
+	v := validator.New()
+	ValidateBookingChange(v, booking, unchanged)
+	require.Contains(t, v.Errors, "booking")
+
+	moreGuests := *unchanged
+	moreGuests.ToGuests = 3
+	v = validator.New()
+	ValidateBookingChange(v, booking, &moreGuests)
+	require.True(t, v.Valid())
+
+	booking.Children = 3
+	v = validator.New()
+	ValidateBookingChange(v, booking, &moreGuests)
+	require.Contains(t, v.Errors, "guests")
+	booking.Children = 0
+
+	booking.Status = BookingCancelled
+	v = validator.New()
+	ValidateBookingChange(v, booking, &moreGuests)
+	require.Contains(t, v.Errors, "status")
+}
+
+func TestNewBookingChange(t *testing.T) {
+	checkIn := time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)
+	booking := &Booking{ID: 7, CheckIn: checkIn, CheckOut: checkIn.AddDate(0, 0, 2), Guests: 1, Total: 200}
+	quote := buildQuote(QuoteRequest{CheckIn: checkIn, CheckOut: checkIn.AddDate(0, 0, 3), Guests: 1}, quotePricing{price: 100})
+
+	change := NewBookingChange(booking, quote, 3)
+	require.Equal(t, ChangePending, change.Status)
+	require.Equal(t, checkIn.AddDate(0, 0, 3), change.ToCheckOut)
+	require.Equal(t, int64(100), change.PriceDifference())
+}
+
+// noSettle stands in for the payment step of Apply where no money moves.
+func noSettle() error {
+	return nil
+}
+
+func TestBookingChangeModel_Apply(t *testing.T) {
+	user := CreateRandomUser(t)
+	listing := CreateRandomListing(t, user)
+	checkIn := truncateDate(time.Now().AddDate(0, 0, 300))
+
+	booking := &Booking{
+		ListingID: listing.ID,
+		GuestID:   user.ID,
+		CheckIn:   checkIn,
+		CheckOut:  checkIn.AddDate(0, 0, 2),
+		Price:     listing.Price,
+		Total:     listing.Price * 2,
+		LineItems: buildQuote(QuoteRequest{CheckIn: checkIn, CheckOut: checkIn.AddDate(0, 0, 2), Guests: 1}, quotePricing{price: listing.Price}).LineItems(),
+	}
+	require.NoError(t, testQueries.Bookings.Insert(booking))
+
+	quote := buildQuote(QuoteRequest{CheckIn: checkIn, CheckOut: checkIn.AddDate(0, 0, 3), Guests: 1}, quotePricing{price: listing.Price})
+	change := NewBookingChange(booking, quote, user.ID)
+	require.NoError(t, testQueries.Changes.Insert(change))
+
+	second := NewBookingChange(booking, quote, user.ID)
+	require.ErrorIs(t, testQueries.Changes.Insert(second), ErrPendingChange)
+
+	items, err := testQueries.LineItems.GetForBooking(booking.ID)
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+
+	require.NoError(t, testQueries.Changes.Apply(booking, change, quote.LineItems(), noSettle))
+	require.Equal(t, ChangeApplied, change.Status)
+	require.Equal(t, quote.Total, booking.Total)
+
+	items, err = testQueries.LineItems.GetForBooking(booking.ID)
+	require.NoError(t, err)
+	require.Len(t, items, 3)
+
+	stored, err := testQueries.Bookings.Get(booking.ID)
+	require.NoError(t, err)
+	require.True(t, stored.CheckOut.Equal(checkIn.AddDate(0, 0, 3)))
+
+	require.ErrorIs(t, testQueries.Changes.Apply(booking, change, quote.LineItems(), noSettle), ErrEditConflict)
+
+	// The failed apply wrote nothing.
+	items, err = testQueries.LineItems.GetForBooking(booking.ID)
+	require.NoError(t, err)
+	require.Len(t, items, 3)
+
+	changes, err := testQueries.Changes.GetForBooking(booking.ID)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+}
+
+func TestBookingChangeModel_Apply_Conflict(t *testing.T) {
+	user := CreateRandomUser(t)
+	listing := CreateRandomListing(t, user)
+	checkIn := truncateDate(time.Now().AddDate(0, 0, 320))
+
+	first := &Booking{ListingID: listing.ID, GuestID: user.ID, CheckIn: checkIn, CheckOut: checkIn.AddDate(0, 0, 2), Price: 1, Total: 2}
+	second := &Booking{ListingID: listing.ID, GuestID: user.ID, CheckIn: checkIn.AddDate(0, 0, 3), CheckOut: checkIn.AddDate(0, 0, 5), Price: 1, Total: 2}
+	require.NoError(t, testQueries.Bookings.Insert(first))
+	require.NoError(t, testQueries.Bookings.Insert(second))
+
+	quote := buildQuote(QuoteRequest{CheckIn: checkIn, CheckOut: checkIn.AddDate(0, 0, 4), Guests: 1}, quotePricing{price: 1})
+	change := NewBookingChange(first, quote, user.ID)
+	require.NoError(t, testQueries.Changes.Insert(change))
+
+	err := testQueries.Changes.Apply(first, change, quote.LineItems(), noSettle)
+	require.ErrorIs(t, err, ErrBookingConflict)
+
+	conflict, err := testQueries.Bookings.FindConflict(listing.ID, checkIn, checkIn.AddDate(0, 0, 4), first.ID)
+	require.NoError(t, err)
+	require.NotNil(t, conflict)
+	require.True(t, conflict.CheckIn.Equal(second.CheckIn))
+
+	require.NoError(t, testQueries.Changes.Decline(change))
+	require.Equal(t, ChangeDeclined, change.Status)
+}
+
+func TestBookingChangeModel_Apply_SettleFails(t *testing.T) {
+	user := CreateRandomUser(t)
+	listing := CreateRandomListing(t, user)
+	checkIn := truncateDate(time.Now().AddDate(0, 0, 340))
+
+	booking := &Booking{ListingID: listing.ID, GuestID: user.ID, CheckIn: checkIn, CheckOut: checkIn.AddDate(0, 0, 2), Price: 1, Total: 2}
+	require.NoError(t, testQueries.Bookings.Insert(booking))
+
+	quote := buildQuote(QuoteRequest{CheckIn: checkIn, CheckOut: checkIn.AddDate(0, 0, 4), Guests: 1}, quotePricing{price: 1})
+	change := NewBookingChange(booking, quote, user.ID)
+	require.NoError(t, testQueries.Changes.Insert(change))
+
+	declined := errors.New("payment declined")
+	err := testQueries.Changes.Apply(booking, change, quote.LineItems(), func() error { return declined })
+	require.ErrorIs(t, err, declined)
+
+	// Nothing was applied, so the host can still decide on the change.
+	stored, err := testQueries.Bookings.Get(booking.ID)
+	require.NoError(t, err)
+	require.True(t, stored.CheckOut.Equal(checkIn.AddDate(0, 0, 2)))
+	require.Equal(t, int64(2), stored.Total)
+
+	items, err := testQueries.LineItems.GetForBooking(booking.ID)
+	require.NoError(t, err)
+	require.Empty(t, items)
+
+	pending, err := testQueries.Changes.Get(change.ID, booking.ID)
+	require.NoError(t, err)
+	require.Equal(t, ChangePending, pending.Status)
+}
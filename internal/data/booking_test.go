@@ -1,8 +1,11 @@
 package data
 
 import (
+	"errors"
 	"github.com/air-bnb/internal/random"
+	"github.com/air-bnb/internal/validator"
 	"github.com/stretchr/testify/require"
+	"sync"
 	"testing"
 	"time"
 )
@@ -11,7 +14,7 @@ func TestBookingModel_Insert(t *testing.T) {
 	user := CreateRandomUser(t)
 	listing := CreateRandomListing(t, user)
 	randCheckIn := int(random.RandInt(1, 100))
-	randCheckOut := int(random.RandInt(1, 100))
+	randCheckOut := randCheckIn + int(random.RandInt(1, 10))
 	booking := &Booking{
 		ListingID: listing.ID,
 		GuestID:   user.ID,
@@ -33,7 +36,7 @@ func TestBookingModel_Delete(t *testing.T) {
 	user := CreateRandomUser(t)
 	listing := CreateRandomListing(t, user)
 	randCheckIn := int(random.RandInt(1, 100))
-	randCheckOut := int(random.RandInt(1, 100))
+	randCheckOut := randCheckIn + int(random.RandInt(1, 10))
 	booking := &Booking{
 		ListingID: listing.ID,
 		GuestID:   user.ID,
@@ -54,7 +57,7 @@ func TestBookingModel_Get(t *testing.T) {
 	user := CreateRandomUser(t)
 	listing := CreateRandomListing(t, user)
 	randCheckIn := int(random.RandInt(1, 100))
-	randCheckOut := int(random.RandInt(1, 100))
+	randCheckOut := randCheckIn + int(random.RandInt(1, 10))
 	booking := &Booking{
 		ListingID: listing.ID,
 		GuestID:   user.ID,
@@ -76,8 +79,8 @@ func TestBookingModel_GetForListing(t *testing.T) {
 	user := CreateRandomUser(t)
 	listing := CreateRandomListing(t, user)
 	for i := 0; i < 10; i++ {
-		randCheckIn := int(random.RandInt(1, 100))
-		randCheckOut := int(random.RandInt(1, 100))
+		randCheckIn := i*10 + 1
+		randCheckOut := randCheckIn + int(random.RandInt(1, 9))
 		booking := &Booking{
 			ListingID: listing.ID,
 			GuestID:   user.ID,
@@ -94,7 +97,7 @@ func TestBookingModel_GetForListing(t *testing.T) {
 
 	}
 
-	bookings, err := testQueries.Bookings.GetForListing(listing.ID)
+	bookings, err := testQueries.Bookings.GetForListing(listing.ID, "")
 	require.NoError(t, err)
 	require.NotEmpty(t, bookings)
 	require.Len(t, bookings, 10)
@@ -105,8 +108,8 @@ func TestBookingModel_GetForUser(t *testing.T) {
 	user := CreateRandomUser(t)
 	listing := CreateRandomListing(t, user)
 	for i := 0; i < 10; i++ {
-		randCheckIn := int(random.RandInt(1, 100))
-		randCheckOut := int(random.RandInt(1, 100))
+		randCheckIn := i*10 + 1
+		randCheckOut := randCheckIn + int(random.RandInt(1, 9))
 		booking := &Booking{
 			ListingID: listing.ID,
 			GuestID:   user.ID,
@@ -123,8 +126,261 @@ func TestBookingModel_GetForUser(t *testing.T) {
 
 	}
 
-	bookings, err := testQueries.Bookings.GetForUser(user.ID)
+	bookings, _, err := testQueries.Bookings.GetForUser(user.ID, "", Filters{Sort: "-created_at", SortSafelist: []string{"-created_at"}})
 	require.NoError(t, err)
 	require.NotEmpty(t, bookings)
 	require.Len(t, bookings, 10)
 }
+
+func TestBookingModel_Insert_Overlap(t *testing.T) {
+	user := CreateRandomUser(t)
+	listing := CreateRandomListing(t, user)
+	checkIn := time.Now().AddDate(0, 0, 10)
+
+	booking := &Booking{
+		ListingID: listing.ID,
+		GuestID:   user.ID,
+		CheckIn:   checkIn,
+		CheckOut:  checkIn.AddDate(0, 0, 5),
+		Price:     listing.Price,
+		Total:     listing.Price * 5,
+	}
+	err := testQueries.Bookings.Insert(booking)
+	require.NoError(t, err)
+
+	overlapping := &Booking{
+		ListingID: listing.ID,
+		GuestID:   user.ID,
+		CheckIn:   checkIn.AddDate(0, 0, 2),
+		CheckOut:  checkIn.AddDate(0, 0, 7),
+		Price:     listing.Price,
+		Total:     listing.Price * 5,
+	}
+	err = testQueries.Bookings.Insert(overlapping)
+	require.ErrorIs(t, err, ErrBookingConflict)
+
+	var conflict *BookingConflictError
+	require.True(t, errors.As(err, &conflict))
+	require.Equal(t, checkIn.Format(DateLayout), conflict.CheckIn.Format(DateLayout))
+	require.Equal(t, checkIn.AddDate(0, 0, 5).Format(DateLayout), conflict.CheckOut.Format(DateLayout))
+
+	adjacent := &Booking{
+		ListingID: listing.ID,
+		GuestID:   user.ID,
+		CheckIn:   checkIn.AddDate(0, 0, 5),
+		CheckOut:  checkIn.AddDate(0, 0, 6),
+		Price:     listing.Price,
+		Total:     listing.Price,
+	}
+	err = testQueries.Bookings.Insert(adjacent)
+	require.NoError(t, err)
+}
+
+func TestBookingModel_Insert_Race(t *testing.T) {
+	user := CreateRandomUser(t)
+	listing := CreateRandomListing(t, user)
+	checkIn := time.Now().AddDate(0, 0, 10)
+
+	var wg sync.WaitGroup
+	errs := make([]error, 5)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = testQueries.Bookings.Insert(&Booking{
+				ListingID: listing.ID,
+				GuestID:   user.ID,
+				CheckIn:   checkIn,
+				CheckOut:  checkIn.AddDate(0, 0, 3),
+				Price:     listing.Price,
+				Total:     listing.Price * 3,
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		require.ErrorIs(t, err, ErrBookingConflict)
+	}
+	require.Equal(t, 1, succeeded)
+}
+
+func TestValidateBooking_Dates(t *testing.T) {
+	checkIn := time.Now().AddDate(0, 0, 10)
+	booking := &Booking{
+		CheckIn:  checkIn,
+		CheckOut: checkIn.AddDate(0, 0, -1),
+		Price:    10,
+		Total:    10,
+	}
+
+	v := validator.New()
+	ValidateBooking(v, booking, &Listing{Guests: 2})
+	require.Contains(t, v.Errors, "checkOut")
+}
+
+func TestValidateBooking_Party(t *testing.T) {
+	checkIn := time.Now().AddDate(0, 0, 10)
+	listing := &Listing{Guests: 4, MaxPets: 1}
+
+	tests := []struct {
+		name  string
+		party Booking
+		field string
+	}{
+		{name: "valid", party: Booking{Adults: 2, Children: 2, Infants: 1, Pets: 1}},
+		{name: "no adults", party: Booking{Children: 2}, field: "adults"},
+		{name: "over capacity", party: Booking{Adults: 3, Children: 2}, field: "guests"},
+		{name: "infants don't count", party: Booking{Adults: 4, Infants: MaxInfants}},
+		{name: "too many infants", party: Booking{Adults: 1, Infants: MaxInfants + 1}, field: "infants"},
+		{name: "too many pets", party: Booking{Adults: 1, Pets: 2}, field: "pets"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			booking := tt.party
+			booking.CheckIn = checkIn
+			booking.CheckOut = checkIn.AddDate(0, 0, 2)
+			booking.Price = 10
+			booking.Total = 20
+
+			v := validator.New()
+			ValidateBooking(v, &booking, listing)
+			if tt.field == "" {
+				require.True(t, v.Valid(), v.Errors)
+			} else {
+				require.Contains(t, v.Errors, tt.field)
+			}
+		})
+	}
+
+	v := validator.New()
+	ValidateBooking(v, &Booking{CheckIn: checkIn, CheckOut: checkIn.AddDate(0, 0, 1), Price: 10, Total: 10, Adults: 1, Pets: 1},
+		&Listing{Guests: 2})
+	require.Equal(t, "this listing does not allow pets", v.Errors["pets"])
+}
+
+func TestBooking_Transition(t *testing.T) {
+	booking := &Booking{Status: BookingPending}
+
+	err := booking.Transition(BookingCompleted)
+	require.ErrorIs(t, err, ErrInvalidTransition)
+	require.Equal(t, BookingPending, booking.Status)
+
+	err = booking.Transition(BookingConfirmed)
+	require.NoError(t, err)
+	require.Equal(t, BookingConfirmed, booking.Status)
+	require.NotNil(t, booking.ConfirmedAt)
+
+	err = booking.Transition(BookingCancelled)
+	require.NoError(t, err)
+	require.NotNil(t, booking.CancelledAt)
+
+	err = booking.Transition(BookingConfirmed)
+	require.ErrorIs(t, err, ErrInvalidTransition)
+}
+
+func TestBookingModel_UpdateStatus(t *testing.T) {
+	user := CreateRandomUser(t)
+	listing := CreateRandomListing(t, user)
+	checkIn := time.Now().AddDate(0, 0, 10)
+
+	booking := &Booking{
+		ListingID: listing.ID,
+		GuestID:   user.ID,
+		CheckIn:   checkIn,
+		CheckOut:  checkIn.AddDate(0, 0, 2),
+		Price:     listing.Price,
+		Total:     listing.Price * 2,
+	}
+	err := testQueries.Bookings.Insert(booking)
+	require.NoError(t, err)
+	require.Equal(t, BookingPending, booking.Status)
+
+	err = booking.Transition(BookingCancelled)
+	require.NoError(t, err)
+	err = testQueries.Bookings.UpdateStatus(booking, BookingPending)
+	require.NoError(t, err)
+
+	stale := *booking
+	stale.Status = BookingConfirmed
+	err = testQueries.Bookings.UpdateStatus(&stale, BookingPending)
+	require.ErrorIs(t, err, ErrEditConflict)
+
+	fromDB, err := testQueries.Bookings.Get(booking.ID)
+	require.NoError(t, err)
+	require.Equal(t, BookingCancelled, fromDB.Status)
+	require.NotNil(t, fromDB.CancelledAt)
+
+	cancelled, _, err := testQueries.Bookings.GetForUser(user.ID, BookingCancelled, Filters{Sort: "-created_at", SortSafelist: []string{"-created_at"}})
+	require.NoError(t, err)
+	require.Len(t, cancelled, 1)
+
+	pending, err := testQueries.Bookings.GetForListing(listing.ID, BookingPending)
+	require.NoError(t, err)
+	require.Empty(t, pending)
+
+	rebooked := &Booking{
+		ListingID: listing.ID,
+		GuestID:   user.ID,
+		CheckIn:   checkIn,
+		CheckOut:  checkIn.AddDate(0, 0, 2),
+		Price:     listing.Price,
+		Total:     listing.Price * 2,
+	}
+	err = testQueries.Bookings.Insert(rebooked)
+	require.NoError(t, err)
+}
+
+func TestBooking_Transition_Expired(t *testing.T) {
+	expiresAt := time.Now().Add(-time.Minute)
+	booking := &Booking{Status: BookingPending, ExpiresAt: &expiresAt}
+
+	err := booking.Transition(BookingConfirmed)
+	require.ErrorIs(t, err, ErrRequestExpired)
+	require.Equal(t, BookingPending, booking.Status)
+
+	err = booking.Transition(BookingDeclined)
+	require.NoError(t, err)
+}
+
+func TestBookingModel_ExpirePending(t *testing.T) {
+	user := CreateRandomUser(t)
+	listing := CreateRandomListing(t, user)
+	checkIn := truncateDate(time.Now().AddDate(0, 0, 340))
+	expiresAt := time.Now().Add(-time.Hour)
+
+	booking := &Booking{
+		ListingID: listing.ID,
+		GuestID:   user.ID,
+		CheckIn:   checkIn,
+		CheckOut:  checkIn.AddDate(0, 0, 2),
+		Price:     listing.Price,
+		Total:     listing.Price * 2,
+		ExpiresAt: &expiresAt,
+	}
+	require.NoError(t, testQueries.Bookings.Insert(booking))
+
+	expired, err := testQueries.Bookings.ExpirePending()
+	require.NoError(t, err)
+
+	var found bool
+	for _, b := range expired {
+		if b.ID == booking.ID {
+			found = true
+			require.Equal(t, BookingExpired, b.Status)
+		}
+	}
+	require.True(t, found)
+
+	// The dates are free again.
+	rebooked := *booking
+	rebooked.ExpiresAt = nil
+	rebooked.Status = ""
+	require.NoError(t, testQueries.Bookings.Insert(&rebooked))
+}
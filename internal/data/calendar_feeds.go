@@ -0,0 +1,105 @@
+package data
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"time"
+)
+
+// CalendarFeedHistory is how far back the exported calendar goes. Older stays are of no use to the
+// platforms syncing the feed and would only make it grow forever.
+const CalendarFeedHistory = 60 * 24 * time.Hour
+
+const (
+	FeedEntryBooking = "booking"
+	FeedEntryBlocked = "blocked"
+)
+
+type CalendarFeedModel struct {
+	DB *sql.DB
+}
+
+// CalendarFeedEntry is one booking or blocked range in a listing's exported calendar. Note holds
+// the block's reason; bookings carry the party size instead.
+type CalendarFeedEntry struct {
+	Kind      string
+	ID        int64
+	Status    string
+	StartDate time.Time
+	EndDate   time.Time
+	Guests    int64
+	Note      string
+}
+
+// Rotate issues a new feed token for the listing, which stops the previous one from working. The
+// plaintext is only ever returned here.
+func (m CalendarFeedModel) Rotate(listingID int64) (string, error) {
+	plaintext, hash, err := generateSecret()
+	if err != nil {
+		return "", err
+	}
+
+	query := `INSERT INTO listing_calendar_feeds (listing_id, token_hash)
+			  VALUES ($1, $2)
+			  ON CONFLICT (listing_id) DO UPDATE SET token_hash = EXCLUDED.token_hash, created_at = NOW()`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err = m.DB.ExecContext(ctx, query, listingID, hash)
+	if err != nil {
+		return "", err
+	}
+
+	return plaintext, nil
+}
+
+// Authenticate reports whether plaintext is the listing's current feed token.
+func (m CalendarFeedModel) Authenticate(listingID int64, plaintext string) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM listing_calendar_feeds WHERE listing_id = $1 AND token_hash = $2)`
+
+	hash := sha256.Sum256([]byte(plaintext))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var ok bool
+	err := m.DB.QueryRowContext(ctx, query, listingID, hash[:]).Scan(&ok)
+	return ok, err
+}
+
+// GetEntries returns the listing's live bookings and blocked dates that end on or after since.
+func (m CalendarFeedModel) GetEntries(listingID int64, since time.Time) ([]*CalendarFeedEntry, error) {
+	query := `SELECT 'booking', id, status, check_in, check_out, guests, '' FROM bookings
+			  WHERE listing_id = $1 AND status IN ('pending', 'confirmed') AND check_out >= $2
+			  UNION ALL
+			  SELECT 'blocked', id, '', start_date, end_date, 0, reason FROM blocked_dates
+			  WHERE listing_id = $1 AND end_date >= $2
+			  ORDER BY 4, 2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, listingID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*CalendarFeedEntry{}
+	for rows.Next() {
+		var entry CalendarFeedEntry
+		err := rows.Scan(&entry.Kind, &entry.ID, &entry.Status, &entry.StartDate, &entry.EndDate, &entry.Guests, &entry.Note)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, &entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,47 @@
+package data
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestCalendarFeedModel(t *testing.T) {
+	user := CreateRandomUser(t)
+	listing := CreateRandomListing(t, user)
+	checkIn := truncateDate(time.Now().AddDate(0, 0, 340))
+
+	token, err := testQueries.Calendars.Rotate(listing.ID)
+	require.NoError(t, err)
+	require.Len(t, token, 26)
+
+	ok, err := testQueries.Calendars.Authenticate(listing.ID, token)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = testQueries.Calendars.Authenticate(listing.ID+1, token)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	booking := &Booking{
+		ListingID: listing.ID,
+		GuestID:   user.ID,
+		CheckIn:   checkIn,
+		CheckOut:  checkIn.AddDate(0, 0, 2),
+		Adults:    2,
+		Price:     listing.Price,
+		Total:     listing.Price * 2,
+	}
+	require.NoError(t, testQueries.Bookings.Insert(booking))
+	block := CreateBlockedDate(t, listing.ID, checkIn.AddDate(0, 0, 5), 3)
+
+	entries, err := testQueries.Calendars.GetEntries(listing.ID, time.Now())
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.Equal(t, FeedEntryBooking, entries[0].Kind)
+	require.Equal(t, booking.ID, entries[0].ID)
+	require.Equal(t, int64(2), entries[0].Guests)
+	require.Equal(t, FeedEntryBlocked, entries[1].Kind)
+	require.Equal(t, block.ID, entries[1].ID)
+	require.Equal(t, block.Reason, entries[1].Note)
+}
@@ -0,0 +1,261 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/air-bnb/internal/validator"
+	"github.com/jackc/pgx/v5/pgconn"
+	"net/url"
+	"time"
+)
+
+// CalendarSyncInterval is how often each external calendar is polled.
+const CalendarSyncInterval = time.Hour
+
+var ErrDuplicateImport = errors.New("calendar is already imported")
+
+type CalendarImportModel struct {
+	DB *sql.DB
+}
+
+// CalendarImport is an external .ics feed whose events block dates on a listing. LastAttemptedAt is
+// set on every sync and LastSyncedAt only on successful ones; LastError holds why the latest sync
+// failed and is empty once a sync succeeds again.
+type CalendarImport struct {
+	ID              int64      `json:"id"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	ListingID       int64      `json:"listingId"`
+	Name            string     `json:"name"`
+	URL             string     `json:"url"`
+	LastAttemptedAt *time.Time `json:"lastAttemptedAt"`
+	LastSyncedAt    *time.Time `json:"lastSyncedAt"`
+	LastError       string     `json:"lastError"`
+	EventCount      int64      `json:"eventCount"`
+}
+
+const calendarImportColumns = `id, created_at, listing_id, name, url, last_attempted_at, last_synced_at,
+			  last_error, event_count`
+
+func (c *CalendarImport) scanDest() []interface{} {
+	return []interface{}{
+		&c.ID,
+		&c.CreatedAt,
+		&c.ListingID,
+		&c.Name,
+		&c.URL,
+		&c.LastAttemptedAt,
+		&c.LastSyncedAt,
+		&c.LastError,
+		&c.EventCount,
+	}
+}
+
+func ValidateCalendarImport(v *validator.Validator, imp *CalendarImport) {
+	v.Check(len(imp.Name) <= 255, "name", "must not be more than 255 characters long")
+
+	v.Check(imp.URL != "", "url", "must be provided")
+	v.Check(len(imp.URL) <= 2000, "url", "must not be more than 2000 characters long")
+
+	u, err := url.Parse(imp.URL)
+	v.Check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", "url",
+		"must be an http or https URL")
+}
+
+func (m CalendarImportModel) Insert(imp *CalendarImport) error {
+	query := `INSERT INTO calendar_imports (listing_id, name, url)
+			  VALUES ($1, $2, $3)
+			  RETURNING id, created_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, imp.ListingID, imp.Name, imp.URL).Scan(&imp.ID, &imp.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		switch {
+		case errors.As(err, &pgErr) && pgErr.ConstraintName == "calendar_imports_listing_url_key":
+			return ErrDuplicateImport
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m CalendarImportModel) Get(id, listingID int64) (*CalendarImport, error) {
+	query := `SELECT ` + calendarImportColumns + `
+			  FROM calendar_imports
+			  WHERE id = $1 AND listing_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var imp CalendarImport
+	err := m.DB.QueryRowContext(ctx, query, id, listingID).Scan(imp.scanDest()...)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &imp, nil
+}
+
+func (m CalendarImportModel) GetForListing(listingID int64) ([]*CalendarImport, error) {
+	query := `SELECT ` + calendarImportColumns + `
+			  FROM calendar_imports
+			  WHERE listing_id = $1
+			  ORDER BY id`
+
+	return m.query(query, listingID)
+}
+
+// GetDue returns up to limit imports that haven't been attempted since before, least recently
+// attempted first.
+func (m CalendarImportModel) GetDue(before time.Time, limit int) ([]*CalendarImport, error) {
+	query := `SELECT ` + calendarImportColumns + `
+			  FROM calendar_imports
+			  WHERE last_attempted_at IS NULL OR last_attempted_at < $1
+			  ORDER BY last_attempted_at NULLS FIRST
+			  LIMIT $2`
+
+	return m.query(query, before, limit)
+}
+
+func (m CalendarImportModel) query(query string, args ...interface{}) ([]*CalendarImport, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	imports := []*CalendarImport{}
+	for rows.Next() {
+		var imp CalendarImport
+		err := rows.Scan(imp.scanDest()...)
+		if err != nil {
+			return nil, err
+		}
+		imports = append(imports, &imp)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return imports, nil
+}
+
+// Delete removes the import together with the dates it blocked.
+func (m CalendarImportModel) Delete(id, listingID int64) error {
+	query := `DELETE FROM calendar_imports WHERE id = $1 AND listing_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id, listingID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// ImportedEvent is a busy range read from an external calendar.
+type ImportedEvent struct {
+	UID       string
+	StartDate time.Time
+	EndDate   time.Time
+	Summary   string
+}
+
+// Sync makes the import's blocked dates match events: blocks are added or moved by UID, and
+// blocks whose event is no longer in the feed are removed. It records the sync on imp.
+func (m CalendarImportModel) Sync(imp *CalendarImport, events []ImportedEvent) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	upsert := `INSERT INTO blocked_dates (listing_id, start_date, end_date, reason, import_id, external_uid)
+			   VALUES ($1, $2, $3, $4, $5, $6)
+			   ON CONFLICT (import_id, external_uid) DO UPDATE
+			   SET start_date = EXCLUDED.start_date, end_date = EXCLUDED.end_date, reason = EXCLUDED.reason
+			   RETURNING id`
+
+	kept := make([]int64, 0, len(events))
+	for _, event := range events {
+		var id int64
+		err := tx.QueryRowContext(ctx, upsert, imp.ListingID, event.StartDate, event.EndDate, event.Summary,
+			imp.ID, event.UID).Scan(&id)
+		if err != nil {
+			return err
+		}
+		kept = append(kept, id)
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM blocked_dates WHERE import_id = $1 AND id <> ALL($2::bigint[])`,
+		imp.ID, SavePgIntArray(kept))
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE calendar_imports
+			  SET last_attempted_at = NOW(), last_synced_at = NOW(), last_error = '', event_count = $1
+			  WHERE id = $2
+			  RETURNING last_attempted_at, last_synced_at`
+
+	err = tx.QueryRowContext(ctx, query, len(events), imp.ID).Scan(&imp.LastAttemptedAt, &imp.LastSyncedAt)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	imp.LastError = ""
+	imp.EventCount = int64(len(events))
+
+	return nil
+}
+
+// RecordError stores why a sync failed. The blocks from the last successful sync are left alone, so
+// a feed that is briefly unreachable doesn't free dates that are still taken elsewhere.
+func (m CalendarImportModel) RecordError(imp *CalendarImport, syncErr error) error {
+	query := `UPDATE calendar_imports SET last_attempted_at = NOW(), last_error = $1
+			  WHERE id = $2
+			  RETURNING last_attempted_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, syncErr.Error(), imp.ID).Scan(&imp.LastAttemptedAt)
+	if err != nil {
+		return err
+	}
+
+	imp.LastError = syncErr.Error()
+	return nil
+}
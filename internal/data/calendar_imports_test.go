@@ -0,0 +1,38 @@
+package data
+
+import (
+	"github.com/air-bnb/internal/validator"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestValidateCalendarImport(t *testing.T) {
+	tests := map[string]bool{
+		"https://calendar.example.com/feed.ics": true,
+		"http://example.com/ical?token=abc":     true,
+		"":                                      false,
+		"webcal://example.com/feed.ics":         false,
+		"file:///etc/passwd":                    false,
+		"https://":                              false,
+	}
+
+	for url, valid := range tests {
+		v := validator.New()
+		ValidateCalendarImport(v, &CalendarImport{URL: url})
+		require.Equal(t, valid, v.Valid(), url)
+	}
+}
+
+func TestCalendarImportModel_Insert_Duplicate(t *testing.T) {
+	user := CreateRandomUser(t)
+	listing := CreateRandomListing(t, user)
+
+	imp := &CalendarImport{ListingID: listing.ID, URL: "https://calendar.example.com/feed.ics"}
+	require.NoError(t, testQueries.Imports.Insert(imp))
+	require.ErrorIs(t, testQueries.Imports.Insert(&CalendarImport{ListingID: listing.ID, URL: imp.URL}), ErrDuplicateImport)
+
+	imports, err := testQueries.Imports.GetForListing(listing.ID)
+	require.NoError(t, err)
+	require.Len(t, imports, 1)
+	require.Nil(t, imports[0].LastSyncedAt)
+}
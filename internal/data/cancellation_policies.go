@@ -0,0 +1,199 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/air-bnb/internal/validator"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	PolicyFlexible = "flexible"
+	PolicyModerate = "moderate"
+	PolicyStrict   = "strict"
+	PolicyCustom   = "custom"
+)
+
+var CancellationPolicyNames = []string{PolicyFlexible, PolicyModerate, PolicyStrict, PolicyCustom}
+
+// CancellationTier refunds RefundPercent of the booking total when the guest cancels at least
+// DaysBefore days before check-in.
+type CancellationTier struct {
+	DaysBefore    int64 `json:"daysBefore"`
+	RefundPercent int64 `json:"refundPercent"`
+}
+
+// cancellationPresets are the tiers of the named policies. Cancelling on the day of check-in is
+// zero days before it; once the stay has started nothing is refunded.
+var cancellationPresets = map[string][]CancellationTier{
+	PolicyFlexible: {{DaysBefore: 1, RefundPercent: 100}},
+	PolicyModerate: {{DaysBefore: 5, RefundPercent: 100}, {DaysBefore: 0, RefundPercent: 50}},
+	PolicyStrict:   {{DaysBefore: 14, RefundPercent: 100}, {DaysBefore: 7, RefundPercent: 50}},
+}
+
+type CancellationPolicyModel struct {
+	DB *sql.DB
+}
+
+type CancellationPolicy struct {
+	ListingID int64              `json:"listingId"`
+	Name      string             `json:"name"`
+	Tiers     []CancellationTier `json:"tiers"`
+}
+
+// DefaultCancellationPolicy is used for listings whose host never chose a policy.
+func DefaultCancellationPolicy(listingID int64) *CancellationPolicy {
+	return &CancellationPolicy{ListingID: listingID, Name: PolicyFlexible, Tiers: cancellationPresets[PolicyFlexible]}
+}
+
+func ValidateCancellationPolicy(v *validator.Validator, policy *CancellationPolicy) {
+	v.Check(validator.PermittedValue(policy.Name, CancellationPolicyNames...), "name", "invalid cancellation policy")
+	if policy.Name != PolicyCustom {
+		return
+	}
+
+	v.Check(len(policy.Tiers) > 0, "tiers", "must contain at least one tier")
+	v.Check(len(policy.Tiers) <= 10, "tiers", "must not contain more than 10 tiers")
+
+	days := make([]int64, 0, len(policy.Tiers))
+	for _, tier := range policy.Tiers {
+		days = append(days, tier.DaysBefore)
+		v.Check(tier.DaysBefore >= 0 && tier.DaysBefore <= 365, "tiers", "daysBefore must be between 0 and 365")
+		v.Check(tier.RefundPercent >= 0 && tier.RefundPercent <= 100, "tiers", "refundPercent must be between 0 and 100")
+	}
+	v.Check(validator.Unique(days), "tiers", "must not contain two tiers with the same daysBefore")
+}
+
+// SetName switches the policy to name, taking the preset tiers unless name is PolicyCustom.
+func (p *CancellationPolicy) SetName(name string) {
+	p.Name = name
+	if tiers, ok := cancellationPresets[name]; ok {
+		p.Tiers = tiers
+	}
+}
+
+// Refund is how much of total goes back to a guest who cancels at now. The most generous tier the
+// guest still qualifies for applies.
+func (p *CancellationPolicy) Refund(total int64, checkIn, now time.Time) int64 {
+	daysBefore := int64(truncateDate(checkIn).Sub(truncateDate(now)).Hours() / 24)
+	if daysBefore < 0 {
+		return 0
+	}
+
+	var percent int64
+	for _, tier := range p.Tiers {
+		if daysBefore >= tier.DaysBefore && tier.RefundPercent > percent {
+			percent = tier.RefundPercent
+		}
+	}
+
+	return total * percent / 100
+}
+
+// Get returns the listing's policy, falling back to DefaultCancellationPolicy when none was saved.
+func (m CancellationPolicyModel) Get(listingID int64) (*CancellationPolicy, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return getCancellationPolicy(ctx, m.DB, listingID)
+}
+
+// getCancellationPolicy is Get on any connection, so that a booking can snapshot the policy in the
+// transaction that creates it.
+func getCancellationPolicy(ctx context.Context, q rowQuerier, listingID int64) (*CancellationPolicy, error) {
+	query := `SELECT listing_id, name, tier_days, tier_refund_percents
+			  FROM cancellation_policies
+			  WHERE listing_id = $1`
+
+	var policy CancellationPolicy
+	var days, percents string
+
+	err := q.QueryRowContext(ctx, query, listingID).Scan(&policy.ListingID, &policy.Name, &days, &percents)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return DefaultCancellationPolicy(listingID), nil
+		default:
+			return nil, err
+		}
+	}
+
+	if policy.Name != PolicyCustom {
+		policy.Tiers = cancellationPresets[policy.Name]
+		return &policy, nil
+	}
+
+	tierDays, tierPercents := LoadPgIntArray(days), LoadPgIntArray(percents)
+	for i := range tierDays {
+		policy.Tiers = append(policy.Tiers, CancellationTier{DaysBefore: tierDays[i], RefundPercent: tierPercents[i]})
+	}
+
+	return &policy, nil
+}
+
+func (m CancellationPolicyModel) Upsert(policy *CancellationPolicy) error {
+	query := `INSERT INTO cancellation_policies (listing_id, name, tier_days, tier_refund_percents)
+			  VALUES ($1, $2, $3, $4)
+			  ON CONFLICT (listing_id) DO UPDATE
+			  SET name = EXCLUDED.name, tier_days = EXCLUDED.tier_days,
+			  tier_refund_percents = EXCLUDED.tier_refund_percents, updated_at = NOW()`
+
+	// Preset tiers are not stored so that changing a preset applies to every listing using it.
+	days, percents := []int64{}, []int64{}
+	if policy.Name == PolicyCustom {
+		sort.Slice(policy.Tiers, func(i, j int) bool {
+			return policy.Tiers[i].DaysBefore > policy.Tiers[j].DaysBefore
+		})
+		for _, tier := range policy.Tiers {
+			days = append(days, tier.DaysBefore)
+			percents = append(percents, tier.RefundPercent)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, query, policy.ListingID, policy.Name, SavePgIntArray(days), SavePgIntArray(percents))
+	return err
+}
+
+// cancellationTiers scans the "days:percent" pairs, comma-separated, that bookingColumns selects
+// for a booking's policy snapshot.
+type cancellationTiers []CancellationTier
+
+func (t *cancellationTiers) Scan(src interface{}) error {
+	var s string
+	switch src := src.(type) {
+	case string:
+		s = src
+	case []byte:
+		s = string(src)
+	default:
+		return fmt.Errorf("cannot scan %T into cancellation tiers", src)
+	}
+
+	*t = cancellationTiers{}
+	if s == "" {
+		return nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		days, percent, _ := strings.Cut(pair, ":")
+		var tier CancellationTier
+		var err error
+		tier.DaysBefore, err = strconv.ParseInt(days, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid cancellation tier %q: %w", pair, err)
+		}
+		tier.RefundPercent, err = strconv.ParseInt(percent, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid cancellation tier %q: %w", pair, err)
+		}
+		*t = append(*t, tier)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package data
+
+import (
+	"github.com/air-bnb/internal/validator"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestCancellationPolicy_Refund(t *testing.T) {
+	checkIn := time.Date(2024, time.July, 20, 0, 0, 0, 0, time.UTC)
+	daysBefore := func(days int) time.Time {
+		return checkIn.AddDate(0, 0, -days).Add(15 * time.Hour)
+	}
+
+	tests := []struct {
+		name   string
+		policy string
+		now    time.Time
+		want   int64
+	}{
+		{"flexible day before", PolicyFlexible, daysBefore(1), 1000},
+		{"flexible on the day", PolicyFlexible, daysBefore(0), 0},
+		{"moderate five days before", PolicyModerate, daysBefore(5), 1000},
+		{"moderate four days before", PolicyModerate, daysBefore(4), 500},
+		{"moderate after check-in", PolicyModerate, daysBefore(-1), 0},
+		{"strict two weeks before", PolicyStrict, daysBefore(14), 1000},
+		{"strict ten days before", PolicyStrict, daysBefore(10), 500},
+		{"strict six days before", PolicyStrict, daysBefore(6), 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := DefaultCancellationPolicy(1)
+			policy.SetName(tt.policy)
+			require.Equal(t, tt.want, policy.Refund(1000, checkIn, tt.now))
+		})
+	}
+}
+
+func TestCancellationPolicy_Refund_Custom(t *testing.T) {
+	checkIn := time.Date(2024, time.July, 20, 0, 0, 0, 0, time.UTC)
+	policy := &CancellationPolicy{
+		Name:  PolicyCustom,
+		Tiers: []CancellationTier{{DaysBefore: 3, RefundPercent: 25}, {DaysBefore: 30, RefundPercent: 90}},
+	}
+
+	require.Equal(t, int64(900), policy.Refund(1000, checkIn, checkIn.AddDate(0, 0, -45)))
+	require.Equal(t, int64(250), policy.Refund(1000, checkIn, checkIn.AddDate(0, 0, -3)))
+	require.Equal(t, int64(0), policy.Refund(1000, checkIn, checkIn.AddDate(0, 0, -2)))
+}
+
+func TestValidateCancellationPolicy(t *testing.T) {
+	v := validator.New()
+	ValidateCancellationPolicy(v, &CancellationPolicy{Name: "lenient"})
+	require.Contains(t, v.Errors, "name")
+
+	v = validator.New()
+	ValidateCancellationPolicy(v, &CancellationPolicy{Name: PolicyCustom})
+	require.Contains(t, v.Errors, "tiers")
+
+	v = validator.New()
+	ValidateCancellationPolicy(v, &CancellationPolicy{
+		Name:  PolicyCustom,
+		Tiers: []CancellationTier{{DaysBefore: 7, RefundPercent: 50}, {DaysBefore: 7, RefundPercent: 120}},
+	})
+	require.Contains(t, v.Errors, "tiers")
+}
+
+func TestCancellationPolicyModel(t *testing.T) {
+	user := CreateRandomUser(t)
+	listing := CreateRandomListing(t, user)
+
+	policy, err := testQueries.Cancellation.Get(listing.ID)
+	require.NoError(t, err)
+	require.Equal(t, PolicyFlexible, policy.Name)
+
+	policy.SetName(PolicyCustom)
+	policy.Tiers = []CancellationTier{{DaysBefore: 2, RefundPercent: 40}, {DaysBefore: 21, RefundPercent: 100}}
+	require.NoError(t, testQueries.Cancellation.Upsert(policy))
+
+	stored, err := testQueries.Cancellation.Get(listing.ID)
+	require.NoError(t, err)
+	require.Equal(t, PolicyCustom, stored.Name)
+	require.Equal(t, []CancellationTier{{DaysBefore: 21, RefundPercent: 100}, {DaysBefore: 2, RefundPercent: 40}}, stored.Tiers)
+
+	stored.SetName(PolicyStrict)
+	require.NoError(t, testQueries.Cancellation.Upsert(stored))
+
+	stored, err = testQueries.Cancellation.Get(listing.ID)
+	require.NoError(t, err)
+	require.Equal(t, PolicyStrict, stored.Name)
+	require.Len(t, stored.Tiers, 2)
+}
+
+func TestCancellationPolicyModel_BookingSnapshot(t *testing.T) {
+	user := CreateRandomUser(t)
+	listing := CreateRandomListing(t, user)
+
+	policy := DefaultCancellationPolicy(listing.ID)
+	policy.SetName(PolicyStrict)
+	require.NoError(t, testQueries.Cancellation.Upsert(policy))
+
+	booking := &Booking{
+		ListingID: listing.ID,
+		GuestID:   user.ID,
+		CheckIn:   time.Now().AddDate(0, 0, 30),
+		CheckOut:  time.Now().AddDate(0, 0, 32),
+		Price:     listing.Price,
+		Total:     listing.Price * 2,
+	}
+	require.NoError(t, testQueries.Bookings.Insert(booking))
+
+	policy.SetName(PolicyFlexible)
+	require.NoError(t, testQueries.Cancellation.Upsert(policy))
+
+	stored, err := testQueries.Bookings.Get(booking.ID)
+	require.NoError(t, err)
+	require.Equal(t, PolicyStrict, stored.CancellationPolicy.Name)
+	require.Equal(t, cancellationPresets[PolicyStrict], stored.CancellationPolicy.Tiers)
+}
+
+func TestCancellationTiers_Scan(t *testing.T) {
+	var tiers cancellationTiers
+	require.NoError(t, tiers.Scan("14:100,7:50"))
+	require.Equal(t, cancellationTiers{{DaysBefore: 14, RefundPercent: 100}, {DaysBefore: 7, RefundPercent: 50}}, tiers)
+
+	require.NoError(t, tiers.Scan(""))
+	require.Empty(t, tiers)
+
+	require.Error(t, tiers.Scan("14"))
+}
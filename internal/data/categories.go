@@ -0,0 +1,186 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/air-bnb/internal/validator"
+	"github.com/jackc/pgx/v5/pgconn"
+	"regexp"
+	"time"
+)
+
+var (
+	ErrDuplicateCategory = errors.New("a category with this slug already exists")
+	ErrUnknownCategory   = errors.New("unknown category")
+	ErrCategoryInUse     = errors.New("category is still used by listings")
+
+	CategorySlugRX = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+)
+
+type CategoryModel struct {
+	DB *sql.DB
+}
+
+// Category is an entry in the taxonomy listings are filed under. Listings refer to it by Slug, which
+// never changes. Listings is how many published listings are filed under it, and is only filled in
+// by GetAll.
+type Category struct {
+	Slug     string `json:"slug"`
+	Name     string `json:"name"`
+	Icon     string `json:"icon"`
+	Position int64  `json:"position"`
+	Listings int64  `json:"listings"`
+}
+
+func ValidateCategory(v *validator.Validator, category *Category) {
+	v.Check(validateCategorySlug(category.Slug), "slug", "must be lowercase words of letters and digits joined by hyphens")
+
+	v.Check(category.Name != "", "name", "must be provided")
+	v.Check(len(category.Name) <= 255, "name", "must not be more than 255 characters long")
+
+	v.Check(len(category.Icon) <= 255, "icon", "must not be more than 255 characters long")
+	v.Check(category.Position >= 0, "position", "must not be negative")
+}
+
+// validateCategorySlug reports whether slug is well formed. Whether it is in the taxonomy is only
+// known once it is saved.
+func validateCategorySlug(slug string) bool {
+	return len(slug) <= 64 && validator.Matches(slug, CategorySlugRX)
+}
+
+// GetAll returns every category in display order, with how many published listings each has.
+func (m CategoryModel) GetAll() ([]*Category, error) {
+	query := `SELECT c.slug, c.name, c.icon, c.position, count(l.id)
+			  FROM categories c
+			  LEFT JOIN listings l ON l.category = c.slug AND l.status = 'published'
+			  GROUP BY c.slug
+			  ORDER BY c.position, c.name`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []*Category{}
+	for rows.Next() {
+		var category Category
+		err := rows.Scan(&category.Slug, &category.Name, &category.Icon, &category.Position, &category.Listings)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, &category)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return categories, nil
+}
+
+func (m CategoryModel) Get(slug string) (*Category, error) {
+	query := `SELECT slug, name, icon, position FROM categories WHERE slug = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var category Category
+	err := m.DB.QueryRowContext(ctx, query, slug).Scan(&category.Slug, &category.Name, &category.Icon, &category.Position)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &category, nil
+}
+
+func (m CategoryModel) Insert(category *Category) error {
+	query := `INSERT INTO categories (slug, name, icon, position) VALUES ($1, $2, $3, $4)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, query, category.Slug, category.Name, category.Icon, category.Position)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		switch {
+		case errors.As(err, &pgErr) && pgErr.ConstraintName == "categories_pkey":
+			return ErrDuplicateCategory
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Update changes how the category is shown. Its slug stays the same.
+func (m CategoryModel) Update(category *Category) error {
+	query := `UPDATE categories SET name = $1, icon = $2, position = $3 WHERE slug = $4`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, category.Name, category.Icon, category.Position, category.Slug)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Delete removes a category. It returns ErrCategoryInUse while listings are still filed under it.
+func (m CategoryModel) Delete(slug string) error {
+	query := `DELETE FROM categories WHERE slug = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, slug)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		switch {
+		case errors.As(err, &pgErr) && pgErr.ConstraintName == "listings_category_fkey":
+			return ErrCategoryInUse
+		default:
+			return err
+		}
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// listingCategoryError turns the error for a listing filed under a category that isn't in the
+// taxonomy into ErrUnknownCategory.
+func listingCategoryError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.ConstraintName == "listings_category_fkey" {
+		return ErrUnknownCategory
+	}
+	return err
+}
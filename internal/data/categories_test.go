@@ -0,0 +1,92 @@
+package data
+
+import (
+	"github.com/air-bnb/internal/random"
+	"github.com/air-bnb/internal/validator"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func CreateRandomCategory(t *testing.T) Category {
+	category := Category{
+		Slug:     "test-" + random.RandString(10),
+		Name:     random.RandString(10),
+		Icon:     random.RandString(5),
+		Position: random.RandInt(1, 100),
+	}
+	require.NoError(t, testQueries.Categories.Insert(&category))
+
+	return category
+}
+
+func TestValidateCategory(t *testing.T) {
+	v := validator.New()
+	ValidateCategory(v, &Category{Slug: "tiny-homes", Name: "Tiny homes"})
+	require.True(t, v.Valid(), v.Errors)
+
+	for _, slug := range []string{"", "Tiny homes", "tiny--homes", "-tiny", "tiny_homes"} {
+		v = validator.New()
+		ValidateCategory(v, &Category{Slug: slug, Name: "Tiny homes"})
+		require.Contains(t, v.Errors, "slug", slug)
+	}
+}
+
+func TestCategoryModel_Insert_Duplicate(t *testing.T) {
+	category := CreateRandomCategory(t)
+
+	err := testQueries.Categories.Insert(&category)
+	require.ErrorIs(t, err, ErrDuplicateCategory)
+}
+
+func TestCategoryModel_GetAll(t *testing.T) {
+	user := CreateRandomUser(t)
+	category := CreateRandomCategory(t)
+	for i := 0; i < 2; i++ {
+		listing := CreateRandomListing(t, user)
+		listing.Category = category.Slug
+		require.NoError(t, testQueries.Listings.Update(&listing))
+	}
+
+	categories, err := testQueries.Categories.GetAll()
+	require.NoError(t, err)
+
+	var found *Category
+	for _, c := range categories {
+		if c.Slug == category.Slug {
+			found = c
+		}
+	}
+	require.NotNil(t, found)
+	require.Equal(t, category.Name, found.Name)
+	require.Equal(t, int64(2), found.Listings)
+}
+
+func TestCategoryModel_Delete_InUse(t *testing.T) {
+	user := CreateRandomUser(t)
+	category := CreateRandomCategory(t)
+	listing := CreateRandomListing(t, user)
+	listing.Category = category.Slug
+	require.NoError(t, testQueries.Listings.Update(&listing))
+
+	err := testQueries.Categories.Delete(category.Slug)
+	require.ErrorIs(t, err, ErrCategoryInUse)
+
+	require.NoError(t, testQueries.Listings.Delete(listing.ID, user.ID))
+	require.NoError(t, testQueries.Categories.Delete(category.Slug))
+
+	_, err = testQueries.Categories.Get(category.Slug)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestListingsModel_Insert_UnknownCategory(t *testing.T) {
+	user := CreateRandomUser(t)
+	listing := CreateRandomListing(t, user)
+	listing.Category = "test-missing-" + random.RandString(8)
+
+	err := testQueries.Listings.Update(&listing)
+	require.ErrorIs(t, err, ErrUnknownCategory)
+
+	listing.ID = 0
+	err = testQueries.Listings.Insert(&listing)
+	require.ErrorIs(t, err, ErrUnknownCategory)
+}
@@ -0,0 +1,53 @@
+package data
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/jackc/pgx/v5/pgconn"
+	"strings"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is a position in a sorted list: just after the row whose sort key is Key and whose ID is
+// ID, or just before it when Before is set. Clients get it as an opaque token from a page's
+// metadata and hand it back for the next or previous page. Unlike a page number it doesn't shift
+// when rows are added or removed in between.
+type Cursor struct {
+	Sort   string `json:"s"`
+	Key    string `json:"k"`
+	ID     int64  `json:"i"`
+	Before bool   `json:"b,omitempty"`
+}
+
+func (c Cursor) Encode() string {
+	js, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(js)
+}
+
+// DecodeCursor reads a token made by Encode. It returns ErrInvalidCursor for anything else.
+func DecodeCursor(token string) (*Cursor, error) {
+	js, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor Cursor
+	err = json.Unmarshal(js, &cursor)
+	if err != nil || cursor.Sort == "" || cursor.ID < 1 {
+		return nil, ErrInvalidCursor
+	}
+
+	return &cursor, nil
+}
+
+// cursorError turns the error Postgres gives for a cursor key that doesn't fit its sort column
+// into ErrInvalidCursor. Keys come from clients, so a tampered one must not look like a server error.
+func cursorError(filters Filters, err error) error {
+	var pgErr *pgconn.PgError
+	if filters.Cursor != nil && errors.As(err, &pgErr) && strings.HasPrefix(pgErr.Code, "22") {
+		return ErrInvalidCursor
+	}
+	return err
+}
@@ -0,0 +1,28 @@
+package data
+
+import "time"
+
+// DateLayout is the format used for calendar dates in query strings and messages.
+const DateLayout = "2006-01-02"
+
+// truncateDate drops the time of day so that dates compare by calendar day.
+func truncateDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// nightsBetween returns each night of a stay, starting on check-in and ending the night before check-out.
+func nightsBetween(checkIn, checkOut time.Time) []time.Time {
+	var nights []time.Time
+	for d := truncateDate(checkIn); d.Before(truncateDate(checkOut)); d = d.AddDate(0, 0, 1) {
+		nights = append(nights, d)
+	}
+	return nights
+}
+
+// nightCount is how many nights nightsBetween would return, worked out without visiting them so that
+// absurd ranges from a query string cost nothing. Ranges too long for a time.Duration saturate, which
+// still counts far more nights than any limit allows.
+func nightCount(checkIn, checkOut time.Time) int64 {
+	nights := int64(truncateDate(checkOut).Sub(truncateDate(checkIn)) / (24 * time.Hour))
+	return max(nights, 0)
+}
@@ -1,24 +1,35 @@
 package data
 
 import (
+	"fmt"
 	"github.com/air-bnb/internal/validator"
 	"math"
+	"slices"
 	"strings"
 )
 
+// Filters holds paging and sorting, and the typed listing filters for endpoints that search
+// listings. A zero ListingFilters filters nothing. With a Cursor the page starts from it instead
+// of from Page, and the total isn't counted.
 type Filters struct {
 	Page         int
 	PageSize     int
 	Sort         string
 	SortSafelist []string
+	Cursor       *Cursor
+	ListingFilters
 }
 
+// Metadata describes a page. Next and Prev are cursors for the pages either side of it, when there
+// are any.
 type Metadata struct {
-	CurrentPage  int `json:"currentPage,omitempty"`
-	PageSize     int `json:"pageSize,omitempty"`
-	FirstPage    int `json:"firstPage,omitempty"`
-	LastPage     int `json:"lastPage,omitempty"`
-	TotalRecords int `json:"totalRecords,omitempty"`
+	CurrentPage  int    `json:"currentPage,omitempty"`
+	PageSize     int    `json:"pageSize,omitempty"`
+	FirstPage    int    `json:"firstPage,omitempty"`
+	LastPage     int    `json:"lastPage,omitempty"`
+	TotalRecords int    `json:"totalRecords,omitempty"`
+	Next         string `json:"next,omitempty"`
+	Prev         string `json:"prev,omitempty"`
 }
 
 func (f Filters) sortColumn() string {
@@ -54,6 +65,13 @@ func ValidateFilters(v *validator.Validator, f Filters) {
 	v.Check(f.PageSize <= 100, "page_size", "must be a maximum of 100")
 
 	v.Check(validator.PermittedValue(f.Sort, f.SortSafelist...), "sort", "invalid sort value")
+
+	if f.Cursor != nil {
+		v.Check(f.Cursor.Sort == f.Sort, "cursor", "was made for a different sort")
+		v.Check(f.Page == 1, "page", "cannot be combined with cursor")
+	}
+
+	validateListingFilters(v, f.ListingFilters)
 }
 
 func calculateMetadata(totalRecord, page, pageSize int) Metadata {
@@ -70,3 +88,90 @@ func calculateMetadata(totalRecord, page, pageSize int) Metadata {
 	}
 
 }
+
+// sortKey is an expression rows are sorted by and the type its text form casts back to.
+type sortKey struct {
+	expr string
+	typ  string
+}
+
+// sortKey returns the key for the sort column out of the ones a query offers.
+func (f Filters) sortKey(keys map[string]sortKey) sortKey {
+	key, ok := keys[f.sortColumn()]
+	if !ok {
+		panic("no sort key for: " + f.Sort)
+	}
+	return key
+}
+
+// pageSQL returns the condition that resumes after the cursor (empty without one), the ORDER BY
+// and the LIMIT for a page sorted by key and then by id. Ties are broken in the sort's direction so
+// that a single row comparison finds the cursor's place. Going backwards reads the rows in reverse.
+// One row more than the page is read to tell whether more follow; page drops it again.
+func (f Filters) pageSQL(args *queryArgs, key sortKey, id string) (where, orderBy, limit string) {
+	direction := f.sortDirection()
+	if f.Cursor != nil && f.Cursor.Before {
+		direction = map[string]string{"ASC": "DESC", "DESC": "ASC"}[direction]
+	}
+	orderBy = fmt.Sprintf("%s %s, %s %s", key.expr, direction, id, direction)
+
+	if f.Cursor != nil {
+		op := ">"
+		if direction == "DESC" {
+			op = "<"
+		}
+		where = fmt.Sprintf("(%s, %s) %s (%s::%s, %s)", key.expr, id, op, args.add(f.Cursor.Key), key.typ, args.add(f.Cursor.ID))
+	}
+
+	if f.PageSize > 0 {
+		limit = fmt.Sprintf("LIMIT %s OFFSET %s", args.add(f.limit()+1), args.add(f.offset()))
+	}
+
+	return where, orderBy, limit
+}
+
+// pageRow is a row read for a page together with the text of its sort key, for its cursor.
+type pageRow[T any] struct {
+	row T
+	key string
+	id  int64
+}
+
+// page turns the rows read with pageSQL into the page and its metadata. Without a page size every
+// row is returned and there is no metadata.
+func page[T any](f Filters, totalRecords int, rows []pageRow[T]) ([]T, Metadata) {
+	var metadata Metadata
+	if f.PageSize > 0 {
+		more := len(rows) > f.limit()
+		if more {
+			rows = rows[:f.limit()]
+		}
+
+		if f.Cursor == nil {
+			metadata = calculateMetadata(totalRecords, f.Page, f.PageSize)
+		} else {
+			metadata.PageSize = f.PageSize
+		}
+
+		hasNext, hasPrev := more, f.Page > 1 || f.Cursor != nil
+		if f.Cursor != nil && f.Cursor.Before {
+			slices.Reverse(rows)
+			hasNext, hasPrev = true, more
+		}
+
+		if len(rows) > 0 && hasNext {
+			last := rows[len(rows)-1]
+			metadata.Next = Cursor{Sort: f.Sort, Key: last.key, ID: last.id}.Encode()
+		}
+		if len(rows) > 0 && hasPrev {
+			metadata.Prev = Cursor{Sort: f.Sort, Key: rows[0].key, ID: rows[0].id, Before: true}.Encode()
+		}
+	}
+
+	page := make([]T, len(rows))
+	for i, row := range rows {
+		page[i] = row.row
+	}
+
+	return page, metadata
+}
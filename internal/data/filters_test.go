@@ -0,0 +1,128 @@
+package data
+
+import (
+	"github.com/air-bnb/internal/validator"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestValidateFilters(t *testing.T) {
+	checkIn := time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)
+	valid := Filters{Page: 1, PageSize: 20, Sort: "price", SortSafelist: []string{"price", "-price"}}
+
+	tests := []struct {
+		name    string
+		filters ListingFilters
+		field   string
+	}{
+		{name: "none"},
+		{name: "all", filters: ListingFilters{MinPrice: 50, MaxPrice: 100, MinBedrooms: 2, Guests: 4,
+			Categories: []string{"beach", "barns"}, Flag: "HR", CheckIn: checkIn, CheckOut: checkIn.AddDate(0, 0, 3)}},
+		{name: "price range inverted", filters: ListingFilters{MinPrice: 100, MaxPrice: 50}, field: "price_max"},
+		{name: "negative bedrooms", filters: ListingFilters{MinBedrooms: -1}, field: "min_bedrooms"},
+		{name: "duplicate category", filters: ListingFilters{Categories: []string{"beach", "beach"}}, field: "category"},
+		{name: "category name", filters: ListingFilters{Categories: []string{"Beach"}}, field: "category"},
+		{name: "malformed amenity", filters: ListingFilters{Amenities: []string{"wifi", "Free Parking"}}, field: "amenities"},
+		{name: "check-in only", filters: ListingFilters{CheckIn: checkIn}, field: "check_in"},
+		{name: "check-out before check-in", filters: ListingFilters{CheckIn: checkIn, CheckOut: checkIn.AddDate(0, 0, -1)}, field: "check_out"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filters := valid
+			filters.ListingFilters = tt.filters
+
+			v := validator.New()
+			ValidateFilters(v, filters)
+			if tt.field == "" {
+				require.True(t, v.Valid(), v.Errors)
+			} else {
+				require.Contains(t, v.Errors, tt.field)
+			}
+		})
+	}
+}
+
+func TestListingFilters_Where(t *testing.T) {
+	var args queryArgs
+	conditions := ListingFilters{MinPrice: 10, Categories: []string{"Beach", "Cabin'; DROP TABLE listings; --"}}.where(&args)
+
+	require.Equal(t, []string{"l.price >= $1", "l.category IN ($2, $3)"}, conditions)
+	require.Equal(t, queryArgs{int64(10), "Beach", "Cabin'; DROP TABLE listings; --"}, args)
+}
+
+func TestDecodeCursor(t *testing.T) {
+	cursor := Cursor{Sort: "-price", Key: "90", ID: 12, Before: true}
+	decoded, err := DecodeCursor(cursor.Encode())
+	require.NoError(t, err)
+	require.Equal(t, cursor, *decoded)
+
+	for _, token := range []string{"", "not base64!", "bm90IGpzb24", Cursor{Sort: "-price"}.Encode()} {
+		_, err := DecodeCursor(token)
+		require.ErrorIs(t, err, ErrInvalidCursor, token)
+	}
+}
+
+func TestValidateFilters_Cursor(t *testing.T) {
+	filters := Filters{Page: 1, PageSize: 20, Sort: "price", SortSafelist: []string{"price", "-price"}}
+
+	filters.Cursor = &Cursor{Sort: "price", Key: "90", ID: 12}
+	v := validator.New()
+	ValidateFilters(v, filters)
+	require.True(t, v.Valid(), v.Errors)
+
+	filters.Cursor = &Cursor{Sort: "-price", Key: "90", ID: 12}
+	filters.Page = 2
+	v = validator.New()
+	ValidateFilters(v, filters)
+	require.Contains(t, v.Errors, "cursor")
+	require.Contains(t, v.Errors, "page")
+}
+
+func TestFilters_PageSQL(t *testing.T) {
+	key := sortKey{expr: "l.price", typ: "integer"}
+	filters := Filters{Page: 1, PageSize: 2, Sort: "-price", SortSafelist: []string{"-price"}}
+
+	var args queryArgs
+	where, orderBy, limit := filters.pageSQL(&args, key, "l.id")
+	require.Empty(t, where)
+	require.Equal(t, "l.price DESC, l.id DESC", orderBy)
+	require.Equal(t, "LIMIT $1 OFFSET $2", limit)
+	require.Equal(t, queryArgs{3, 0}, args)
+
+	filters.Cursor = &Cursor{Sort: "-price", Key: "90", ID: 12, Before: true}
+	args = nil
+	where, orderBy, _ = filters.pageSQL(&args, key, "l.id")
+	require.Equal(t, "(l.price, l.id) > ($1::integer, $2)", where)
+	require.Equal(t, "l.price ASC, l.id ASC", orderBy)
+	require.Equal(t, queryArgs{"90", int64(12), 3, 0}, args)
+}
+
+func TestPage(t *testing.T) {
+	rows := func(ids ...int64) []pageRow[int64] {
+		var rows []pageRow[int64]
+		for _, id := range ids {
+			rows = append(rows, pageRow[int64]{row: id, key: "k", id: id})
+		}
+		return rows
+	}
+	filters := Filters{Page: 1, PageSize: 2, Sort: "id"}
+
+	ids, metadata := page(filters, 5, rows(1, 2, 3))
+	require.Equal(t, []int64{1, 2}, ids)
+	require.Equal(t, 5, metadata.TotalRecords)
+	require.Equal(t, Cursor{Sort: "id", Key: "k", ID: 2}.Encode(), metadata.Next)
+	require.Empty(t, metadata.Prev)
+
+	// A backwards page is read in reverse, and there is always a page after it.
+	filters.Cursor = &Cursor{Sort: "id", Key: "k", ID: 5, Before: true}
+	ids, metadata = page(filters, 0, rows(4, 3))
+	require.Equal(t, []int64{3, 4}, ids)
+	require.Equal(t, Cursor{Sort: "id", Key: "k", ID: 4}.Encode(), metadata.Next)
+	require.Empty(t, metadata.Prev)
+
+	ids, metadata = page(Filters{Sort: "id"}, 3, rows(1, 2, 3))
+	require.Equal(t, []int64{1, 2, 3}, ids)
+	require.Equal(t, Metadata{}, metadata)
+}
@@ -0,0 +1,139 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// HoldTTL is how long a checkout hold keeps the dates aside.
+const HoldTTL = 10 * time.Minute
+
+var ErrDatesHeld = errors.New("dates are held by another guest")
+
+type HoldModel struct {
+	DB *sql.DB
+}
+
+type Hold struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	ListingID int64     `json:"listingId"`
+	GuestID   int64     `json:"guestId"`
+	CheckIn   time.Time `json:"checkIn"`
+	CheckOut  time.Time `json:"checkOut"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// lockListingDates takes a lock on the listing row for the rest of tx and fails with ErrDatesHeld if
+// another guest holds any of the dates. Every write that claims dates goes through it, so a hold and
+// a booking for the same dates can't both succeed.
+func lockListingDates(ctx context.Context, tx *sql.Tx, listingID int64, checkIn, checkOut time.Time, guestID int64) error {
+	_, err := tx.ExecContext(ctx, `SELECT id FROM listings WHERE id = $1 FOR UPDATE`, listingID)
+	if err != nil {
+		return err
+	}
+
+	query := `SELECT EXISTS (
+			      SELECT 1 FROM booking_holds
+			      WHERE listing_id = $1 AND guest_id <> $4 AND expires_at > NOW()
+			      AND daterange(check_in, check_out, '[)') && daterange($2, $3, '[)')
+			  )`
+
+	var held bool
+	err = tx.QueryRowContext(ctx, query, listingID, checkIn, checkOut, guestID).Scan(&held)
+	if err != nil {
+		return err
+	}
+	if held {
+		return ErrDatesHeld
+	}
+
+	return nil
+}
+
+// Insert holds the dates for the guest until HoldTTL from now, replacing any hold the guest already
+// had on the listing. It returns ErrDatesHeld if another guest holds them and a *BookingConflictError
+// if they are already booked.
+func (m HoldModel) Insert(hold *Hold) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = lockListingDates(ctx, tx, hold.ListingID, hold.CheckIn, hold.CheckOut, hold.GuestID)
+	if err != nil {
+		return err
+	}
+
+	conflict, err := findConflict(ctx, tx, hold.ListingID, hold.CheckIn, hold.CheckOut, 0)
+	if err != nil {
+		return err
+	}
+	if conflict != nil {
+		return conflict
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM booking_holds WHERE listing_id = $1 AND guest_id = $2`,
+		hold.ListingID, hold.GuestID)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO booking_holds (listing_id, guest_id, check_in, check_out, expires_at)
+			  VALUES ($1, $2, $3, $4, NOW() + $5 * interval '1 second')
+			  RETURNING id, created_at, expires_at`
+
+	args := []interface{}{hold.ListingID, hold.GuestID, hold.CheckIn, hold.CheckOut, int64(HoldTTL.Seconds())}
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&hold.ID, &hold.CreatedAt, &hold.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Delete releases a hold early, for example when the guest leaves checkout.
+func (m HoldModel) Delete(id, guestID int64) error {
+	query := `DELETE FROM booking_holds WHERE id = $1 AND guest_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id, guestID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// DeleteExpired removes holds past their expiry. Expired holds are already ignored everywhere, so
+// this only keeps the table small.
+func (m HoldModel) DeleteExpired() (int64, error) {
+	query := `DELETE FROM booking_holds WHERE expires_at <= NOW()`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,62 @@
+package data
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestHoldModel_Insert(t *testing.T) {
+	owner := CreateRandomUser(t)
+	other := CreateRandomUser(t)
+	listing := CreateRandomListing(t, owner)
+	checkIn := truncateDate(time.Now().AddDate(0, 0, 320))
+
+	hold := &Hold{ListingID: listing.ID, GuestID: owner.ID, CheckIn: checkIn, CheckOut: checkIn.AddDate(0, 0, 2)}
+	require.NoError(t, testQueries.Holds.Insert(hold))
+	require.NotZero(t, hold.ID)
+	require.WithinDuration(t, time.Now().Add(HoldTTL), hold.ExpiresAt, time.Minute)
+
+	// Holding again replaces the guest's previous hold instead of conflicting with it.
+	again := &Hold{ListingID: listing.ID, GuestID: owner.ID, CheckIn: checkIn, CheckOut: checkIn.AddDate(0, 0, 3)}
+	require.NoError(t, testQueries.Holds.Insert(again))
+	require.ErrorIs(t, testQueries.Holds.Delete(hold.ID, owner.ID), ErrRecordNotFound)
+
+	overlapping := &Hold{ListingID: listing.ID, GuestID: other.ID, CheckIn: checkIn.AddDate(0, 0, 1), CheckOut: checkIn.AddDate(0, 0, 4)}
+	require.ErrorIs(t, testQueries.Holds.Insert(overlapping), ErrDatesHeld)
+
+	booking := &Booking{
+		ListingID: listing.ID,
+		GuestID:   other.ID,
+		CheckIn:   checkIn,
+		CheckOut:  checkIn.AddDate(0, 0, 2),
+		Price:     listing.Price,
+		Total:     listing.Price * 2,
+	}
+	require.ErrorIs(t, testQueries.Bookings.Insert(booking), ErrDatesHeld)
+
+	// The guest holding the dates can book them, which releases the hold.
+	booking.GuestID = owner.ID
+	require.NoError(t, testQueries.Bookings.Insert(booking))
+	require.ErrorIs(t, testQueries.Holds.Delete(again.ID, owner.ID), ErrRecordNotFound)
+
+	var conflict *BookingConflictError
+	require.ErrorAs(t, testQueries.Holds.Insert(overlapping), &conflict)
+}
+
+func TestHoldModel_DeleteExpired(t *testing.T) {
+	user := CreateRandomUser(t)
+	listing := CreateRandomListing(t, user)
+	checkIn := truncateDate(time.Now().AddDate(0, 0, 330))
+
+	hold := &Hold{ListingID: listing.ID, GuestID: user.ID, CheckIn: checkIn, CheckOut: checkIn.AddDate(0, 0, 2)}
+	require.NoError(t, testQueries.Holds.Insert(hold))
+
+	_, err := testQueries.Holds.DB.Exec(`UPDATE booking_holds SET expires_at = NOW() - interval '1 minute' WHERE id = $1`, hold.ID)
+	require.NoError(t, err)
+
+	released, err := testQueries.Holds.DeleteExpired()
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, released, int64(1))
+	require.ErrorIs(t, testQueries.Holds.Delete(hold.ID, user.ID), ErrRecordNotFound)
+}
@@ -0,0 +1,99 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const (
+	LineItemNight         = "night"
+	LineItemDiscount      = "discount"
+	LineItemExtraGuestFee = "extra_guest_fee"
+	LineItemCleaningFee   = "cleaning_fee"
+	LineItemServiceFee    = "service_fee"
+	LineItemTax           = "tax"
+)
+
+type LineItemModel struct {
+	DB *sql.DB
+}
+
+// LineItem is one row of the price breakdown a guest agreed to when booking. Rows are written
+// together with their booking and the database rejects any later update; applying a booking change
+// writes a new set of rows, tagged with its ChangeID, which become the current ones.
+type LineItem struct {
+	ID          int64  `json:"id"`
+	BookingID   int64  `json:"bookingId"`
+	ChangeID    *int64 `json:"changeId,omitempty"`
+	Position    int64  `json:"position"`
+	Kind        string `json:"kind"`
+	Description string `json:"description"`
+	Quantity    int64  `json:"quantity"`
+	UnitAmount  int64  `json:"unitAmount"`
+	Amount      int64  `json:"amount"`
+}
+
+func insertLineItems(ctx context.Context, tx *sql.Tx, bookingID int64, items []*LineItem) error {
+	query := `INSERT INTO booking_line_items (booking_id, change_id, position, kind, description, quantity,
+			  unit_amount, amount)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`
+
+	for _, item := range items {
+		item.BookingID = bookingID
+		args := []interface{}{
+			item.BookingID,
+			item.ChangeID,
+			item.Position,
+			item.Kind,
+			item.Description,
+			item.Quantity,
+			item.UnitAmount,
+			item.Amount,
+		}
+
+		err := tx.QueryRowContext(ctx, query, args...).Scan(&item.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetForBooking returns the booking's current breakdown: the items of the latest applied change, or
+// the original items when no change was ever applied.
+func (m LineItemModel) GetForBooking(bookingID int64) ([]*LineItem, error) {
+	query := `SELECT id, booking_id, change_id, position, kind, description, quantity, unit_amount, amount
+			  FROM booking_line_items
+			  WHERE booking_id = $1
+			  AND change_id IS NOT DISTINCT FROM (
+			      SELECT max(id) FROM booking_changes WHERE booking_id = $1 AND status = 'applied'
+			  )
+			  ORDER BY position`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []*LineItem{}
+	for rows.Next() {
+		var item LineItem
+		err := rows.Scan(&item.ID, &item.BookingID, &item.ChangeID, &item.Position, &item.Kind, &item.Description, &item.Quantity, &item.UnitAmount, &item.Amount)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, &item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
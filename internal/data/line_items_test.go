@@ -0,0 +1,43 @@
+package data
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestBookingModel_Insert_LineItems(t *testing.T) {
+	user := CreateRandomUser(t)
+	listing := CreateRandomListing(t, user)
+	checkIn := time.Now().AddDate(0, 0, 200)
+
+	quote := buildQuote(QuoteRequest{
+		ListingID: listing.ID,
+		CheckIn:   checkIn,
+		CheckOut:  checkIn.AddDate(0, 0, 3),
+		Guests:    1,
+	}, quotePricing{price: listing.Price, cleaningFee: 20})
+
+	booking := &Booking{
+		ListingID: listing.ID,
+		GuestID:   user.ID,
+		CheckIn:   checkIn,
+		CheckOut:  checkIn.AddDate(0, 0, 3),
+		Price:     quote.BasePrice,
+		Total:     quote.Total,
+		LineItems: quote.LineItems(),
+	}
+	err := testQueries.Bookings.Insert(booking)
+	require.NoError(t, err)
+
+	items, err := testQueries.LineItems.GetForBooking(booking.ID)
+	require.NoError(t, err)
+	require.Len(t, items, 4)
+	require.Equal(t, LineItemCleaningFee, items[3].Kind)
+	require.Equal(t, int64(20), items[3].Amount)
+
+	_, err = testQueries.LineItems.DB.ExecContext(context.Background(),
+		`UPDATE booking_line_items SET amount = 0 WHERE id = $1`, items[0].ID)
+	require.Error(t, err)
+}
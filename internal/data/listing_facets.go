@@ -0,0 +1,142 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// PriceFacetWidth is the width of each price histogram bucket.
+	PriceFacetWidth = 50
+	// PriceFacetBuckets is how many price buckets there are. The last one is open-ended.
+	PriceFacetBuckets = 10
+	// BedroomFacetBuckets is how many bedroom buckets there are, one per count from zero. The last
+	// one is open-ended.
+	BedroomFacetBuckets = 6
+	// MaxFacetValues caps the categories and regions returned, keeping the most common.
+	MaxFacetValues = 50
+)
+
+// FacetCount is how many listings have a value.
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// RangeFacetCount is how many listings fall between Min and Max, both inclusive. Max is nil for an
+// open-ended bucket.
+type RangeFacetCount struct {
+	Min   int64  `json:"min"`
+	Max   *int64 `json:"max"`
+	Count int    `json:"count"`
+}
+
+// ListingFacets count the listings a search matches by category, region, bedrooms and price.
+// Categories and regions are most common first and leave out values with no matches. Bedrooms and
+// prices have every bucket, so they can be drawn as a histogram.
+type ListingFacets struct {
+	Categories []FacetCount      `json:"categories"`
+	Regions    []FacetCount      `json:"regions"`
+	Bedrooms   []RangeFacetCount `json:"bedrooms"`
+	Prices     []RangeFacetCount `json:"prices"`
+}
+
+// Facets counts the listings matching search and filters, the same listings GetAll pages through.
+// Every facet comes from one pass over the matches.
+func (m ListingsModel) Facets(search ListingSearch, filters ListingFilters) (*ListingFacets, error) {
+	var args queryArgs
+	conditions := append(search.where(&args), filters.where(&args)...)
+
+	query := fmt.Sprintf(`WITH matches AS (
+				  SELECT l.category, l.location_region AS region, least(l.bedrooms, %d) AS bedrooms,
+				  least(l.price / %d, %d) AS price_bucket
+				  FROM listings l`, BedroomFacetBuckets-1, PriceFacetWidth, PriceFacetBuckets-1)
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, ` AND `)
+	}
+	query += `)
+			  SELECT category, region, bedrooms, price_bucket, count(*)
+			  FROM matches
+			  GROUP BY GROUPING SETS ((category), (region), (bedrooms), (price_bucket))`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	facets := &ListingFacets{
+		Categories: []FacetCount{},
+		Regions:    []FacetCount{},
+		Bedrooms:   rangeFacets(BedroomFacetBuckets, 1),
+		Prices:     rangeFacets(PriceFacetBuckets, PriceFacetWidth),
+	}
+
+	for rows.Next() {
+		var category, region *string
+		var bedrooms, priceBucket *int64
+		var count int
+
+		err := rows.Scan(&category, &region, &bedrooms, &priceBucket, &count)
+		if err != nil {
+			return nil, err
+		}
+
+		// Each row belongs to one grouping set and the other columns are NULL. Category and region
+		// can't be NULL themselves, so a NULL means the row is from another set.
+		switch {
+		case category != nil:
+			facets.Categories = append(facets.Categories, FacetCount{Value: *category, Count: count})
+		case region != nil:
+			if *region != "" {
+				facets.Regions = append(facets.Regions, FacetCount{Value: *region, Count: count})
+			}
+		case bedrooms != nil:
+			facets.Bedrooms[*bedrooms].Count = count
+		case priceBucket != nil:
+			facets.Prices[*priceBucket].Count = count
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	facets.Categories = topFacetCounts(facets.Categories)
+	facets.Regions = topFacetCounts(facets.Regions)
+
+	return facets, nil
+}
+
+// rangeFacets returns n empty buckets of the given width starting at zero.
+func rangeFacets(n int, width int64) []RangeFacetCount {
+	buckets := make([]RangeFacetCount, n)
+	for i := range buckets {
+		buckets[i].Min = int64(i) * width
+		if i < n-1 {
+			max := buckets[i].Min + width - 1
+			buckets[i].Max = &max
+		}
+	}
+	return buckets
+}
+
+func topFacetCounts(counts []FacetCount) []FacetCount {
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Value < counts[j].Value
+	})
+
+	if len(counts) > MaxFacetValues {
+		counts = counts[:MaxFacetValues]
+	}
+	return counts
+}
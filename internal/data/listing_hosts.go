@@ -0,0 +1,97 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// ListingHostModel manages a listing's co-hosts. The owner is not stored here.
+type ListingHostModel struct {
+	DB *sql.DB
+}
+
+// CoHost is a user who helps run a listing, with only the profile fields the owner needs to see.
+type CoHost struct {
+	UserID  int64     `json:"userId"`
+	AddedAt time.Time `json:"addedAt"`
+	Name    string    `json:"name,omitempty"`
+	Email   string    `json:"email"`
+	Image   string    `json:"image,omitempty"`
+}
+
+// GetForListing returns the listing's co-hosts, earliest added first.
+func (m ListingHostModel) GetForListing(listingID int64) ([]*CoHost, error) {
+	query := `SELECT u.id, lh.created_at, COALESCE(u.name, ''), u.email, COALESCE(u.image, '')
+			  FROM listing_hosts lh
+			  INNER JOIN users u ON u.id = lh.user_id
+			  WHERE lh.listing_id = $1
+			  ORDER BY lh.created_at, u.id`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, listingID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cohosts := []*CoHost{}
+	for rows.Next() {
+		var cohost CoHost
+		err := rows.Scan(&cohost.UserID, &cohost.AddedAt, &cohost.Name, &cohost.Email, &cohost.Image)
+		if err != nil {
+			return nil, err
+		}
+		cohosts = append(cohosts, &cohost)
+	}
+
+	return cohosts, rows.Err()
+}
+
+func (m ListingHostModel) Insert(listingID, userID int64) error {
+	query := `INSERT INTO listing_hosts (listing_id, user_id) VALUES ($1, $2)
+			  ON CONFLICT DO NOTHING`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, query, listingID, userID)
+	return err
+}
+
+// Delete removes userID from the listing's co-hosts. It returns ErrRecordNotFound if they weren't one.
+func (m ListingHostModel) Delete(listingID, userID int64) error {
+	query := `DELETE FROM listing_hosts WHERE listing_id = $1 AND user_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, listingID, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// IsCoHost reports whether userID co-hosts the listing.
+func (m ListingHostModel) IsCoHost(listingID, userID int64) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM listing_hosts WHERE listing_id = $1 AND user_id = $2)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var exists bool
+	err := m.DB.QueryRowContext(ctx, query, listingID, userID).Scan(&exists)
+	return exists, err
+}
@@ -0,0 +1,36 @@
+package data
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestListingHostModel(t *testing.T) {
+	owner := CreateRandomUser(t)
+	cohost := CreateRandomUser(t)
+	listing := CreateRandomListing(t, owner)
+
+	ok, err := testQueries.ListingHosts.IsCoHost(listing.ID, cohost.ID)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, testQueries.ListingHosts.Insert(listing.ID, cohost.ID))
+	require.NoError(t, testQueries.ListingHosts.Insert(listing.ID, cohost.ID))
+
+	ok, err = testQueries.ListingHosts.IsCoHost(listing.ID, cohost.ID)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	cohosts, err := testQueries.ListingHosts.GetForListing(listing.ID)
+	require.NoError(t, err)
+	require.Len(t, cohosts, 1)
+	require.Equal(t, cohost.ID, cohosts[0].UserID)
+	require.Equal(t, cohost.Email, cohosts[0].Email)
+
+	require.NoError(t, testQueries.ListingHosts.Delete(listing.ID, cohost.ID))
+	require.ErrorIs(t, testQueries.ListingHosts.Delete(listing.ID, cohost.ID), ErrRecordNotFound)
+
+	ok, err = testQueries.ListingHosts.IsCoHost(listing.ID, cohost.ID)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
@@ -0,0 +1,355 @@
+package data
+
+import (
+	"fmt"
+	"github.com/air-bnb/internal/validator"
+	"html"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	earthRadiusKm = 6371.0
+	// MaxSearchRadiusKm bounds radius searches so that the bounding box stays meaningful.
+	MaxSearchRadiusKm = 500.0
+)
+
+// GeoPoint is a position in decimal degrees.
+type GeoPoint struct {
+	Lat float64
+	Lng float64
+}
+
+// BoundingBox is the area between two corners in decimal degrees. A box whose MinLng is greater
+// than its MaxLng crosses the antimeridian.
+type BoundingBox struct {
+	MinLng float64
+	MinLat float64
+	MaxLng float64
+	MaxLat float64
+}
+
+// ListingSearch narrows ListingsModel.GetAll. Text is a web-style query ("beach house" -camping)
+// matched against the listing's words and ranked by relevance. Center on its own only adds
+// distances; together with RadiusKm it also filters. Distances are measured from Center, or from
+// the middle of BBox when only a box is given.
+type ListingSearch struct {
+	Text     string
+	Center   *GeoPoint
+	RadiusKm float64
+	BBox     *BoundingBox
+}
+
+// MaxFilterCategories caps how many categories one search may select.
+const MaxFilterCategories = 20
+
+// ListingFilters narrow a listing search by what the listing offers. Zero values don't filter.
+// A listing must have every one of Amenities. CheckIn and CheckOut select listings that can be
+// booked for that stay: nothing booked, blocked or held on those nights, and a stay length the
+// host's rules allow.
+type ListingFilters struct {
+	MinPrice     int64
+	MaxPrice     int64
+	MinBedrooms  int64
+	MinBathrooms int64
+	Guests       int64
+	Categories   []string
+	Flag         string
+	Region       string
+	Amenities    []string
+	CheckIn      time.Time
+	CheckOut     time.Time
+}
+
+func validateListingFilters(v *validator.Validator, f ListingFilters) {
+	v.Check(f.MinPrice >= 0, "price_min", "must not be negative")
+	v.Check(f.MaxPrice >= 0, "price_max", "must not be negative")
+	if f.MaxPrice > 0 {
+		v.Check(f.MaxPrice >= f.MinPrice, "price_max", "must not be less than price_min")
+	}
+
+	v.Check(f.MinBedrooms >= 0, "min_bedrooms", "must not be negative")
+	v.Check(f.MinBathrooms >= 0, "min_bathrooms", "must not be negative")
+	v.Check(f.Guests >= 0, "guests", "must not be negative")
+
+	v.Check(len(f.Categories) <= MaxFilterCategories, "category", fmt.Sprintf("must not select more than %d categories", MaxFilterCategories))
+	v.Check(validator.Unique(f.Categories), "category", "must not contain duplicate values")
+	for _, category := range f.Categories {
+		v.Check(validateCategorySlug(category), "category", "must only contain category slugs")
+	}
+
+	v.Check(len(f.Flag) <= 255, "flag", "must not be more than 255 characters long")
+	v.Check(len(f.Region) <= 255, "region", "must not be more than 255 characters long")
+	validateAmenityKeys(v, "amenities", f.Amenities)
+
+	if !f.CheckIn.IsZero() || !f.CheckOut.IsZero() {
+		v.Check(!f.CheckIn.IsZero() && !f.CheckOut.IsZero(), "check_in", "check_in and check_out must be given together")
+		v.Check(f.CheckOut.After(f.CheckIn), "check_out", "must be after check_in")
+		v.Check(nightCount(f.CheckIn, f.CheckOut) <= 365, "check_out", "stay must not be longer than 365 nights")
+	}
+}
+
+// where returns the filters' conditions, each with its own placeholders.
+func (f ListingFilters) where(args *queryArgs) []string {
+	var conditions []string
+
+	if f.MinPrice > 0 {
+		conditions = append(conditions, "l.price >= "+args.add(f.MinPrice))
+	}
+	if f.MaxPrice > 0 {
+		conditions = append(conditions, "l.price <= "+args.add(f.MaxPrice))
+	}
+	if f.MinBedrooms > 0 {
+		conditions = append(conditions, "l.bedrooms >= "+args.add(f.MinBedrooms))
+	}
+	if f.MinBathrooms > 0 {
+		conditions = append(conditions, "l.bathrooms >= "+args.add(f.MinBathrooms))
+	}
+	if f.Guests > 0 {
+		conditions = append(conditions, "l.guests >= "+args.add(f.Guests))
+	}
+
+	if len(f.Categories) > 0 {
+		placeholders := make([]string, len(f.Categories))
+		for i, category := range f.Categories {
+			placeholders[i] = args.add(category)
+		}
+		conditions = append(conditions, "l.category IN ("+strings.Join(placeholders, ", ")+")")
+	}
+
+	if f.Flag != "" {
+		conditions = append(conditions, "lower(l.location_flag) = lower("+args.add(f.Flag)+")")
+	}
+	if f.Region != "" {
+		conditions = append(conditions, "lower(l.location_region) = lower("+args.add(f.Region)+")")
+	}
+
+	// Amenities are unique, so a listing has them all when it has as many of them as were asked for.
+	if len(f.Amenities) > 0 {
+		placeholders := make([]string, len(f.Amenities))
+		for i, amenity := range f.Amenities {
+			placeholders[i] = args.add(amenity)
+		}
+		conditions = append(conditions, fmt.Sprintf(`(SELECT count(*) FROM listing_amenities la
+				WHERE la.listing_id = l.id AND la.amenity_key IN (%s)) = %d`, strings.Join(placeholders, ", "), len(f.Amenities)))
+	}
+
+	if !f.CheckIn.IsZero() && !f.CheckOut.IsZero() {
+		stay := fmt.Sprintf("daterange(%s::date, %s::date, '[)')", args.add(f.CheckIn), args.add(f.CheckOut))
+		nights := args.add(nightCount(f.CheckIn, f.CheckOut))
+		conditions = append(conditions,
+			fmt.Sprintf(`NOT EXISTS (SELECT 1 FROM bookings b WHERE b.listing_id = l.id
+				AND b.status IN ('pending', 'confirmed') AND daterange(b.check_in, b.check_out, '[)') && %s)`, stay),
+			fmt.Sprintf(`NOT EXISTS (SELECT 1 FROM blocked_dates bd WHERE bd.listing_id = l.id
+				AND daterange(bd.start_date, bd.end_date, '[)') && %s)`, stay),
+			fmt.Sprintf(`NOT EXISTS (SELECT 1 FROM booking_holds h WHERE h.listing_id = l.id
+				AND h.expires_at > NOW() AND daterange(h.check_in, h.check_out, '[)') && %s)`, stay),
+			fmt.Sprintf(`NOT EXISTS (SELECT 1 FROM listing_rules lr WHERE lr.listing_id = l.id
+				AND (%[1]s < lr.min_nights OR %[1]s > lr.max_nights))`, nights),
+		)
+	}
+
+	return conditions
+}
+
+func ValidateListingSearch(v *validator.Validator, search ListingSearch) {
+	v.Check(len(search.Text) <= 500, "search", "must not be more than 500 characters long")
+
+	if search.Center != nil {
+		v.Check(search.Center.Lat >= -90 && search.Center.Lat <= 90, "lat", "must be between -90 and 90")
+		v.Check(search.Center.Lng >= -180 && search.Center.Lng <= 180, "lng", "must be between -180 and 180")
+	}
+
+	if search.RadiusKm != 0 {
+		v.Check(search.Center != nil, "radius_km", "requires lat and lng")
+		v.Check(search.RadiusKm > 0 && search.RadiusKm <= MaxSearchRadiusKm, "radius_km",
+			fmt.Sprintf("must be greater than 0 and at most %g", MaxSearchRadiusKm))
+		v.Check(search.BBox == nil, "bbox", "cannot be combined with radius_km")
+	}
+
+	if box := search.BBox; box != nil {
+		v.Check(box.MinLat >= -90 && box.MaxLat <= 90, "bbox", "latitudes must be between -90 and 90")
+		v.Check(box.MinLng >= -180 && box.MaxLng <= 180, "bbox", "longitudes must be between -180 and 180")
+		v.Check(box.MinLat < box.MaxLat, "bbox", "minimum latitude must be below the maximum latitude")
+	}
+}
+
+// origin is the point distances are measured from, or nil when the search has no location.
+func (s ListingSearch) origin() *GeoPoint {
+	switch {
+	case s.Center != nil:
+		return s.Center
+	case s.BBox != nil:
+		return s.BBox.center()
+	default:
+		return nil
+	}
+}
+
+func (b BoundingBox) center() *GeoPoint {
+	lng := (b.MinLng + b.MaxLng) / 2
+	if b.MinLng > b.MaxLng {
+		lng = normalizeLng(lng + 180)
+	}
+	return &GeoPoint{Lat: (b.MinLat + b.MaxLat) / 2, Lng: lng}
+}
+
+// boundingBox returns a box that contains every point within radiusKm of p. Near the poles the box
+// widens to every longitude.
+func (p GeoPoint) boundingBox(radiusKm float64) BoundingBox {
+	dLat := radiusKm / earthRadiusKm * 180 / math.Pi
+	box := BoundingBox{MinLat: math.Max(p.Lat-dLat, -90), MaxLat: math.Min(p.Lat+dLat, 90)}
+
+	if box.MinLat == -90 || box.MaxLat == 90 {
+		box.MinLng, box.MaxLng = -180, 180
+		return box
+	}
+
+	// The circle is widest in longitude a little poleward of p, where sin(r/R) / cos(lat) is reached.
+	ratio := math.Sin(radiusKm/earthRadiusKm) / math.Cos(p.Lat*math.Pi/180)
+	if ratio >= 1 {
+		box.MinLng, box.MaxLng = -180, 180
+		return box
+	}
+	dLng := math.Asin(ratio) * 180 / math.Pi
+	box.MinLng = normalizeLng(p.Lng - dLng)
+	box.MaxLng = normalizeLng(p.Lng + dLng)
+
+	return box
+}
+
+func normalizeLng(lng float64) float64 {
+	for lng > 180 {
+		lng -= 360
+	}
+	for lng < -180 {
+		lng += 360
+	}
+	return lng
+}
+
+// haversineKm is the great-circle distance between a and b.
+func haversineKm(a, b GeoPoint) float64 {
+	lat1, lat2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dLat := lat2 - lat1
+	dLng := (b.Lng - a.Lng) * math.Pi / 180
+
+	h := math.Pow(math.Sin(dLat/2), 2) + math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin(dLng/2), 2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// queryArgs collects the arguments of a query that is built up piece by piece.
+type queryArgs []interface{}
+
+// add appends value and returns its placeholder.
+func (a *queryArgs) add(value interface{}) string {
+	*a = append(*a, value)
+	return fmt.Sprintf("$%d", len(*a))
+}
+
+// distanceSQL is the haversine distance in kilometres from p to the listing, the same formula as
+// haversineKm.
+func distanceSQL(args *queryArgs, p GeoPoint) string {
+	lat, lng := args.add(p.Lat), args.add(p.Lng)
+	return fmt.Sprintf(`(2 * %g * asin(least(1, sqrt(
+				power(sin(radians(l.location_lat - %[2]s) / 2), 2) +
+				cos(radians(%[2]s)) * cos(radians(l.location_lat)) *
+				power(sin(radians(l.location_lng - %[3]s) / 2), 2)))))`, earthRadiusKm, lat, lng)
+}
+
+// boxSQL matches listings inside box using listings_location_idx. A box across the antimeridian is
+// split in two.
+func boxSQL(args *queryArgs, box BoundingBox) string {
+	inside := func(minLng, maxLng float64) string {
+		return fmt.Sprintf(`point(l.location_lng, l.location_lat) <@ box(point(%s, %s), point(%s, %s))`,
+			args.add(minLng), args.add(box.MinLat), args.add(maxLng), args.add(box.MaxLat))
+	}
+
+	if box.MinLng > box.MaxLng {
+		return "(" + inside(box.MinLng, 180) + " OR " + inside(-180, box.MaxLng) + ")"
+	}
+	return inside(box.MinLng, box.MaxLng)
+}
+
+// Headlines mark matched words with these control characters, which can't appear in a listing's
+// text. They are swapped for <mark> tags after the text is HTML-escaped.
+const (
+	highlightStart = "\x02"
+	highlightStop  = "\x03"
+)
+
+// searchSQL is a ListingSearch turned into SQL. Every expression is valid whether or not the search
+// uses it, so the query always has the same columns.
+type searchSQL struct {
+	conditions          []string
+	distance            string
+	relevance           string
+	titleHeadline       string
+	descriptionHeadline string
+}
+
+func (s ListingSearch) sql(args *queryArgs) searchSQL {
+	q := searchSQL{
+		conditions:          s.where(args),
+		distance:            "NULL::double precision",
+		relevance:           "NULL::real",
+		titleHeadline:       "NULL::text",
+		descriptionHeadline: "NULL::text",
+	}
+
+	if s.Text != "" {
+		query := tsQuerySQL(args, s.Text)
+		marks := fmt.Sprintf("StartSel=%s, StopSel=%s", highlightStart, highlightStop)
+		titleOptions := args.add(marks + ", HighlightAll=true")
+		descriptionOptions := args.add(marks + ", MaxFragments=2, MaxWords=30, MinWords=10")
+
+		q.relevance = fmt.Sprintf("ts_rank(l.search_vector, %s)", query)
+		q.titleHeadline = fmt.Sprintf("ts_headline('english', l.title, %s, %s)", query, titleOptions)
+		q.descriptionHeadline = fmt.Sprintf("ts_headline('english', l.description, %s, %s)", query, descriptionOptions)
+	}
+
+	if origin := s.origin(); origin != nil {
+		q.distance = distanceSQL(args, *origin)
+	}
+
+	return q
+}
+
+// where returns the search's conditions on their own, for queries that don't need its columns.
+// Postgres rejects placeholders that the query never uses, so they can't share sql's. Searches only
+// ever find published listings.
+func (s ListingSearch) where(args *queryArgs) []string {
+	conditions := []string{"l.status = 'published'"}
+
+	if s.Text != "" {
+		conditions = append(conditions, "l.search_vector @@ "+tsQuerySQL(args, s.Text))
+	}
+
+	switch {
+	case s.Center != nil && s.RadiusKm > 0:
+		conditions = append(conditions, boxSQL(args, s.Center.boundingBox(s.RadiusKm)))
+		conditions = append(conditions, fmt.Sprintf("%s <= %s", distanceSQL(args, *s.Center), args.add(s.RadiusKm)))
+	case s.BBox != nil:
+		conditions = append(conditions, boxSQL(args, *s.BBox))
+	}
+
+	return conditions
+}
+
+func tsQuerySQL(args *queryArgs, text string) string {
+	return fmt.Sprintf("websearch_to_tsquery('english', %s)", args.add(text))
+}
+
+// ListingHighlights are the listing's title and an excerpt of its description with the words that
+// matched the search wrapped in <mark> tags. The rest of the text is HTML-escaped.
+type ListingHighlights struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+var highlightReplacer = strings.NewReplacer(highlightStart, "<mark>", highlightStop, "</mark>")
+
+func highlight(headline string) string {
+	return highlightReplacer.Replace(html.EscapeString(headline))
+}
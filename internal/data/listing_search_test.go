@@ -0,0 +1,133 @@
+package data
+
+import (
+	"github.com/air-bnb/internal/random"
+	"github.com/air-bnb/internal/validator"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestHaversineKm(t *testing.T) {
+	zagreb := GeoPoint{Lat: 45.815, Lng: 15.982}
+	split := GeoPoint{Lat: 43.508, Lng: 16.440}
+
+	require.InDelta(t, 259, haversineKm(zagreb, split), 2)
+	require.Zero(t, haversineKm(zagreb, zagreb))
+}
+
+func TestGeoPoint_BoundingBox(t *testing.T) {
+	center := GeoPoint{Lat: 45, Lng: 15}
+	box := center.boundingBox(100)
+
+	// Points 100 km due north, south, east and west must all fall inside the box.
+	require.InDelta(t, 100, haversineKm(center, GeoPoint{Lat: box.MaxLat, Lng: 15}), 0.5)
+	require.InDelta(t, 100, haversineKm(center, GeoPoint{Lat: box.MinLat, Lng: 15}), 0.5)
+	require.GreaterOrEqual(t, haversineKm(center, GeoPoint{Lat: 45, Lng: box.MaxLng}), 100.0)
+	require.GreaterOrEqual(t, haversineKm(center, GeoPoint{Lat: 45, Lng: box.MinLng}), 100.0)
+
+	fiji := GeoPoint{Lat: -17, Lng: 179.5}.boundingBox(200)
+	require.Greater(t, fiji.MinLng, fiji.MaxLng, "box should wrap around the antimeridian")
+
+	pole := GeoPoint{Lat: 89.5, Lng: 0}.boundingBox(200)
+	require.Equal(t, BoundingBox{MinLng: -180, MinLat: pole.MinLat, MaxLng: 180, MaxLat: 90}, pole)
+}
+
+func TestValidateListingSearch(t *testing.T) {
+	center := &GeoPoint{Lat: 45, Lng: 15}
+	box := &BoundingBox{MinLng: 14, MinLat: 44, MaxLng: 16, MaxLat: 46}
+
+	tests := []struct {
+		name   string
+		search ListingSearch
+		field  string
+	}{
+		{name: "radius", search: ListingSearch{Center: center, RadiusKm: 10}},
+		{name: "box", search: ListingSearch{BBox: box}},
+		{name: "box across antimeridian", search: ListingSearch{BBox: &BoundingBox{MinLng: 170, MinLat: -20, MaxLng: -170, MaxLat: -10}}},
+		{name: "radius without center", search: ListingSearch{RadiusKm: 10}, field: "radius_km"},
+		{name: "radius too large", search: ListingSearch{Center: center, RadiusKm: 5000}, field: "radius_km"},
+		{name: "radius and box", search: ListingSearch{Center: center, RadiusKm: 10, BBox: box}, field: "bbox"},
+		{name: "latitude out of range", search: ListingSearch{Center: &GeoPoint{Lat: 95}}, field: "lat"},
+		{name: "inverted box", search: ListingSearch{BBox: &BoundingBox{MinLng: 14, MinLat: 46, MaxLng: 16, MaxLat: 44}}, field: "bbox"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := validator.New()
+			ValidateListingSearch(v, tt.search)
+			if tt.field == "" {
+				require.True(t, v.Valid(), v.Errors)
+			} else {
+				require.Contains(t, v.Errors, tt.field)
+			}
+		})
+	}
+}
+
+func TestListingsModel_GetAll_Radius(t *testing.T) {
+	user := CreateRandomUser(t)
+
+	// A random spot in the ocean keeps other test runs' listings out of the results.
+	center := GeoPoint{Lat: -40 + float64(random.RandInt(0, 20)), Lng: -140 + float64(random.RandInt(0, 20))}
+	insert := func(northKm float64) Listing {
+		listing := CreateRandomListing(t, user)
+		lat, lng := center.Lat+northKm/111.195, center.Lng
+		listing.Location.Lat, listing.Location.Lng = &lat, &lng
+		require.NoError(t, testQueries.Listings.Update(&listing))
+		return listing
+	}
+	far := insert(30)
+	near := insert(5)
+	insert(80)
+
+	filters := Filters{Page: 1, PageSize: 10, Sort: "distance", SortSafelist: []string{"distance"}}
+	listings, metadata, err := testQueries.Listings.GetAll(ListingSearch{Center: &center, RadiusKm: 50}, filters)
+	require.NoError(t, err)
+	require.Equal(t, 2, metadata.TotalRecords)
+	require.Equal(t, near.ID, listings[0].ID)
+	require.Equal(t, far.ID, listings[1].ID)
+	require.InDelta(t, 5, *listings[0].DistanceKm, 0.1)
+	require.InDelta(t, 30, *listings[1].DistanceKm, 0.1)
+
+	box := &BoundingBox{MinLng: center.Lng - 1, MinLat: center.Lat + 0.2, MaxLng: center.Lng + 1, MaxLat: center.Lat + 0.5}
+	listings, _, err = testQueries.Listings.GetAll(ListingSearch{BBox: box}, filters)
+	require.NoError(t, err)
+	require.Len(t, listings, 1)
+	require.Equal(t, far.ID, listings[0].ID)
+}
+
+func TestHighlight(t *testing.T) {
+	headline := "Cosy <b>" + highlightStart + "cabin" + highlightStop + "</b> & lake"
+	require.Equal(t, "Cosy &lt;b&gt;<mark>cabin</mark>&lt;/b&gt; &amp; lake", highlight(headline))
+}
+
+func TestListingsModel_GetAll_Text(t *testing.T) {
+	user := CreateRandomUser(t)
+	word := "zq" + random.RandString(8)
+
+	inDescription := CreateRandomListing(t, user)
+	inDescription.Description = "A quiet flat. The " + word + " is a short walk <away>."
+	require.NoError(t, testQueries.Listings.Update(&inDescription))
+
+	inTitle := CreateRandomListing(t, user)
+	inTitle.Title = "Lovely " + word + " retreat"
+	require.NoError(t, testQueries.Listings.Update(&inTitle))
+
+	CreateRandomListing(t, user)
+
+	filters := Filters{Page: 1, PageSize: 10, Sort: "-relevance", SortSafelist: []string{"-relevance"}}
+	listings, metadata, err := testQueries.Listings.GetAll(ListingSearch{Text: word}, filters)
+	require.NoError(t, err)
+	require.Equal(t, 2, metadata.TotalRecords)
+	require.Equal(t, inTitle.ID, listings[0].ID)
+	require.Equal(t, inDescription.ID, listings[1].ID)
+
+	require.Equal(t, "Lovely <mark>"+word+"</mark> retreat", listings[0].Highlights.Title)
+	require.Contains(t, listings[1].Highlights.Description, "<mark>"+word+"</mark>")
+	require.Contains(t, listings[1].Highlights.Description, "&lt;away&gt;")
+
+	listings, _, err = testQueries.Listings.GetAll(ListingSearch{Text: word + " -retreat"}, filters)
+	require.NoError(t, err)
+	require.Len(t, listings, 1)
+	require.Equal(t, inDescription.ID, listings[0].ID)
+}
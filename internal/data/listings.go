@@ -7,72 +7,233 @@ import (
 	"fmt"
 	"github.com/air-bnb/internal/validator"
 	"github.com/jackc/pgx/v5"
+	"strings"
 	"time"
 )
 
+// DefaultRequestExpiryHours is how long hosts have to answer a booking request unless they choose
+// otherwise.
+const DefaultRequestExpiryHours = 24
+
+// A listing starts out as a draft that only its hosts can see. Once it is complete it goes to
+// review, and an admin publishes it or sends it back. Hosts can take a published listing off
+// search for a while by unlisting it, and archive one they no longer rent out.
+const (
+	ListingDraft         = "draft"
+	ListingPendingReview = "pending_review"
+	ListingPublished     = "published"
+	ListingUnlisted      = "unlisted"
+	ListingArchived      = "archived"
+)
+
+// listingTransitions lists the statuses each status may move to.
+var listingTransitions = map[string][]string{
+	ListingDraft:         {ListingPendingReview, ListingArchived},
+	ListingPendingReview: {ListingPublished, ListingDraft, ListingArchived},
+	ListingPublished:     {ListingUnlisted, ListingArchived},
+	ListingUnlisted:      {ListingPublished, ListingArchived},
+	ListingArchived:      {ListingDraft},
+}
+
+var ErrInvalidListingTransition = errors.New("invalid listing status transition")
+
+// MinListingImages is how many photos a listing needs before it can be published.
+const MinListingImages = 1
+
 type ListingsModel struct {
 	DB *sql.DB
 }
 
+// Location is where a listing is. Lat and Lng are nil until the host places the listing on the map,
+// which drafts may not have done yet.
 type Location struct {
-	Flag   string  `json:"flag"`
-	Label  string  `json:"label"`
-	Lat    float64 `json:"lat"`
-	Lng    float64 `json:"lng"`
-	Region string  `json:"region"`
-	Value  string  `json:"value"`
+	Flag   string   `json:"flag"`
+	Label  string   `json:"label"`
+	Lat    *float64 `json:"lat"`
+	Lng    *float64 `json:"lng"`
+	Region string   `json:"region"`
+	Value  string   `json:"value"`
 }
 
 type Listing struct {
-	ID          int64    `json:"id"`
-	CreatedAt   string   `json:"created_at"`
-	Title       string   `json:"title"`
-	Description string   `json:"description"`
-	Category    string   `json:"category"`
-	Bedrooms    int64    `json:"bedrooms"`
-	Bathrooms   int64    `json:"bathrooms"`
-	Guests      int64    `json:"guests"`
-	Location    Location `json:"location"`
-	Price       int64    `json:"price"`
-	OwnerID     int64    `json:"ownerId"`
-	OwnerName   string   `json:"ownerName"`
-	OwnerPhoto  string   `json:"ownerPhoto,omitempty"`
-	Images      []*Image `json:"images,omitempty"`
+	ID                 int64              `json:"id"`
+	CreatedAt          string             `json:"created_at"`
+	Title              string             `json:"title"`
+	Description        string             `json:"description"`
+	Category           string             `json:"category"`
+	Bedrooms           int64              `json:"bedrooms"`
+	Bathrooms          int64              `json:"bathrooms"`
+	Guests             int64              `json:"guests"`
+	Location           Location           `json:"location"`
+	Price              int64              `json:"price"`
+	CleaningFee        int64              `json:"cleaningFee"`
+	ServiceFee         int64              `json:"serviceFeePercent"`
+	InstantBook        bool               `json:"instantBook"`
+	RequestExpiryHours int64              `json:"requestExpiryHours"`
+	MaxPets            int64              `json:"maxPets"`
+	BaseOccupancy      int64              `json:"baseOccupancy"`
+	ExtraGuestFee      int64              `json:"extraGuestFee"`
+	Amenities          []string           `json:"amenities"`
+	Status             string             `json:"status"`
+	PublishedAt        *time.Time         `json:"publishedAt,omitempty"`
+	Version            int32              `json:"version"`
+	OwnerID            int64              `json:"ownerId"`
+	OwnerName          string             `json:"ownerName"`
+	OwnerPhoto         string             `json:"ownerPhoto,omitempty"`
+	DistanceKm         *float64           `json:"distanceKm,omitempty"`
+	Highlights         *ListingHighlights `json:"highlights,omitempty"`
+	Images             []*Image           `json:"images,omitempty"`
+}
+
+// listingColumns matches the destinations returned by Listing.scanDest.
+const listingColumns = `l.id, l.created_at, l.title, l.description, COALESCE(l.category, ''), l.bedrooms,
+			  l.bathrooms, l.guests, l.location_flag, l.location_label, l.location_lat, l.location_lng,
+			  l.location_region, l.location_value, l.price, l.cleaning_fee, l.service_fee_percent,
+			  l.instant_book, l.request_expiry_hours, l.max_pets, l.base_occupancy, l.extra_guest_fee,
+			  array_to_string(ARRAY(SELECT la.amenity_key FROM listing_amenities la
+			  WHERE la.listing_id = l.id ORDER BY la.amenity_key), ','), l.status, l.published_at,
+			  l.version, l.owner_id, u.name, COALESCE(u.image, '')`
+
+func (l *Listing) scanDest() []interface{} {
+	return []interface{}{
+		&l.ID,
+		&l.CreatedAt,
+		&l.Title,
+		&l.Description,
+		&l.Category,
+		&l.Bedrooms,
+		&l.Bathrooms,
+		&l.Guests,
+		&l.Location.Flag,
+		&l.Location.Label,
+		&l.Location.Lat,
+		&l.Location.Lng,
+		&l.Location.Region,
+		&l.Location.Value,
+		&l.Price,
+		&l.CleaningFee,
+		&l.ServiceFee,
+		&l.InstantBook,
+		&l.RequestExpiryHours,
+		&l.MaxPets,
+		&l.BaseOccupancy,
+		&l.ExtraGuestFee,
+		(*amenityKeys)(&l.Amenities),
+		&l.Status,
+		&l.PublishedAt,
+		&l.Version,
+		&l.OwnerID,
+		&l.OwnerName,
+		&l.OwnerPhoto,
+	}
 }
 
+// ValidateListing checks that what the listing has is well formed. Drafts are saved a step at a
+// time, so it doesn't require the text, category, location or price; ValidateListingComplete does,
+// before the listing can be published.
 func ValidateListing(v *validator.Validator, listing *Listing) {
-	v.Check(listing.Title != "", "title", "must be provided")
 	v.Check(len(listing.Title) <= 500, "title", "must not be more than 500 characters long")
-
-	v.Check(listing.Description != "", "description", "must be provided")
 	v.Check(len(listing.Description) <= 5000, "description", "must not be more than 5000 characters long")
 
-	v.Check(listing.Category != "", "category", "must be provided")
-	v.Check(len(listing.Category) <= 255, "category", "must not be more than 255 characters long")
+	if listing.Category != "" {
+		v.Check(validateCategorySlug(listing.Category), "category", "must be a category slug")
+	}
 
 	v.Check(listing.Bedrooms > 0, "bedrooms", "must be greater than zero")
 	v.Check(listing.Bathrooms > 0, "bathrooms", "must be greater than zero")
 	v.Check(listing.Guests > 0, "guests", "must be greater than zero")
 
-	v.Check(listing.Location.Flag != "", "location.flag", "must be provided")
 	v.Check(len(listing.Location.Flag) <= 255, "location.flag", "must not be more than 255 characters long")
-
 	v.Check(len(listing.Location.Label) <= 255, "location.label", "must not be more than 255 characters long")
-
 	v.Check(len(listing.Location.Region) <= 255, "location.region", "must not be more than 255 characters long")
+	v.Check((listing.Location.Lat == nil) == (listing.Location.Lng == nil), "location.latlng",
+		"must be a latitude and a longitude")
+	if lat := listing.Location.Lat; lat != nil {
+		v.Check(*lat >= -90 && *lat <= 90, "location.lat", "must be between -90 and 90")
+	}
+	if lng := listing.Location.Lng; lng != nil {
+		v.Check(*lng >= -180 && *lng <= 180, "location.lng", "must be between -180 and 180")
+	}
 
-	v.Check(listing.Location.Lat != 0, "location.lat", "must be provided")
-	v.Check(listing.Location.Lng != 0, "location.lng", "must be provided")
+	v.Check(listing.Price >= 0, "price", "must not be negative")
+	v.Check(listing.CleaningFee >= 0, "cleaningFee", "must not be negative")
+	v.Check(listing.RequestExpiryHours >= 1 && listing.RequestExpiryHours <= 168, "requestExpiryHours",
+		"must be between 1 and 168")
+	v.Check(listing.MaxPets >= 0 && listing.MaxPets <= 10, "maxPets", "must be between 0 and 10")
+	v.Check(listing.BaseOccupancy >= 1 && listing.BaseOccupancy <= listing.Guests, "baseOccupancy",
+		"must be between 1 and the number of guests")
+	v.Check(listing.ExtraGuestFee >= 0, "extraGuestFee", "must not be negative")
+	ValidateServiceFee(v, listing.ServiceFee)
+	validateAmenityKeys(v, "amenities", listing.Amenities)
+	v.Check(listing.OwnerID > 0, "owner_id", "must be greater than zero")
+}
+
+// ValidateServiceFee checks the platform's service fee, a percentage of the stay.
+func ValidateServiceFee(v *validator.Validator, percent int64) {
+	v.Check(percent >= 0 && percent <= 100, "serviceFeePercent", "must be between 0 and 100")
+}
+
+// ValidateListingComplete checks that the listing, with images photos, has everything guests need
+// to see before it can be published.
+func ValidateListingComplete(v *validator.Validator, listing *Listing, images int) {
+	v.Check(listing.Title != "", "title", "must be provided")
+	v.Check(listing.Description != "", "description", "must be provided")
+	v.Check(listing.Category != "", "category", "must be provided")
+
+	v.Check(listing.Location.Flag != "", "location.flag", "must be provided")
+	v.Check(listing.Location.Lat != nil, "location.lat", "must be provided")
+	v.Check(listing.Location.Lng != nil, "location.lng", "must be provided")
 
 	v.Check(listing.Price > 0, "price", "must be greater than zero")
-	v.Check(listing.OwnerID > 0, "owner_id", "must be greater than zero")
+	v.Check(images >= MinListingImages, "images", fmt.Sprintf("must have at least %d photo(s)", MinListingImages))
 }
 
+// NeedsComplete reports whether the listing is, or is on its way to being, public and so must stay
+// complete when it is edited.
+func (l *Listing) NeedsComplete() bool {
+	return l.Status == ListingPendingReview || l.Status == ListingPublished || l.Status == ListingUnlisted
+}
+
+func (l *Listing) CanTransition(status string) bool {
+	return validator.PermittedValue(status, listingTransitions[l.Status]...)
+}
+
+// Transition moves the listing to status, stamping when it was first published. It only changes the
+// struct; persist it with ListingsModel.UpdateStatus.
+func (l *Listing) Transition(status string) error {
+	if !l.CanTransition(status) {
+		return ErrInvalidListingTransition
+	}
+
+	if status == ListingPublished && l.PublishedAt == nil {
+		now := time.Now()
+		l.PublishedAt = &now
+	}
+	l.Status = status
+
+	return nil
+}
+
+// Insert saves a new listing with its amenities. It returns ErrUnknownCategory or ErrUnknownAmenity
+// if the listing names a category or amenity that doesn't exist.
 func (m ListingsModel) Insert(listing *Listing) error {
+	if listing.RequestExpiryHours == 0 {
+		listing.RequestExpiryHours = DefaultRequestExpiryHours
+	}
+	if listing.BaseOccupancy == 0 {
+		listing.BaseOccupancy = listing.Guests
+	}
+	if listing.Status == "" {
+		listing.Status = ListingDraft
+	}
+
 	query := `INSERT INTO listings (title, description, category, bedrooms, bathrooms,
               guests, location_flag, location_label, location_lat, location_lng, location_region, location_value,
-              price, owner_id)
-			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) RETURNING id, created_at`
+              price, cleaning_fee, service_fee_percent, instant_book, request_expiry_hours, max_pets,
+              base_occupancy, extra_guest_fee, owner_id, status, published_at)
+			  VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19,
+			  $20, $21, $22, CASE WHEN $22 = 'published' THEN NOW() END)
+			  RETURNING id, created_at, published_at, version`
 	args := []interface{}{
 		listing.Title,
 		listing.Description,
@@ -87,23 +248,44 @@ func (m ListingsModel) Insert(listing *Listing) error {
 		listing.Location.Region,
 		listing.Location.Value,
 		listing.Price,
+		listing.CleaningFee,
+		listing.ServiceFee,
+		listing.InstantBook,
+		listing.RequestExpiryHours,
+		listing.MaxPets,
+		listing.BaseOccupancy,
+		listing.ExtraGuestFee,
 		listing.OwnerID,
+		listing.Status,
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
-	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&listing.ID, &listing.CreatedAt)
+
+	tx, err := m.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	return nil
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&listing.ID, &listing.CreatedAt, &listing.PublishedAt, &listing.Version)
+	if err != nil {
+		return listingCategoryError(err)
+	}
+
+	if listing.Amenities == nil {
+		listing.Amenities = []string{}
+	}
+	err = setListingAmenities(ctx, tx, listing.ID, listing.Amenities)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 func (m ListingsModel) Get(id int64) (*Listing, error) {
-	query := `SELECT l.id, l.created_at, l.title, l.description, l.category, l.bedrooms,
-			  l.bathrooms, l.guests, l.location_flag, l.location_label, l.location_lat, l.location_lng,
-			  l.location_region, l.location_value, l.price, l.owner_id, u.name, COALESCE(u.image, '')
+	query := `SELECT ` + listingColumns + `
 			  FROM listings l
 			  INNER JOIN users u ON u.id = l.owner_id
 			  WHERE l.id = $1`
@@ -112,26 +294,7 @@ func (m ListingsModel) Get(id int64) (*Listing, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	err := m.DB.QueryRowContext(ctx, query, id).Scan(
-		&listing.ID,
-		&listing.CreatedAt,
-		&listing.Title,
-		&listing.Description,
-		&listing.Category,
-		&listing.Bedrooms,
-		&listing.Bathrooms,
-		&listing.Guests,
-		&listing.Location.Flag,
-		&listing.Location.Label,
-		&listing.Location.Lat,
-		&listing.Location.Lng,
-		&listing.Location.Region,
-		&listing.Location.Value,
-		&listing.Price,
-		&listing.OwnerID,
-		&listing.OwnerName,
-		&listing.OwnerPhoto,
-	)
+	err := m.DB.QueryRowContext(ctx, query, id).Scan(listing.scanDest()...)
 	if err != nil {
 		switch {
 		case errors.Is(err, pgx.ErrNoRows):
@@ -144,59 +307,67 @@ func (m ListingsModel) Get(id int64) (*Listing, error) {
 	return &listing, nil
 }
 
-func (m ListingsModel) AllUserListings(userID int64) ([]*Listing, error) {
-	query := `SELECT l.id, l.created_at, l.title, l.description, l.category, l.bedrooms,
-			  l.bathrooms, l.guests, l.location_flag, l.location_label, l.location_lat, l.location_lng,
-			  l.location_region, l.location_value, l.price, l.owner_id, u.name, COALESCE(u.image, '')
+// AllUserListings returns the listings the user owns. Without a page size in filters it returns
+// all of them.
+func (m ListingsModel) AllUserListings(userID int64, filters Filters) ([]*Listing, Metadata, error) {
+	args := queryArgs{userID}
+	conditions := []string{"l.owner_id = $1"}
+
+	key := filters.sortKey(map[string]sortKey{
+		"id":         {"l.id", "bigint"},
+		"created_at": {"l.created_at", "timestamp"},
+		"title":      {"l.title", "text"},
+		"price":      {"l.price", "integer"},
+	})
+	after, orderBy, limit := filters.pageSQL(&args, key, "l.id")
+	if after != "" {
+		conditions = append(conditions, after)
+	}
+
+	count := "count(*) OVER()"
+	if filters.Cursor != nil {
+		count = "0"
+	}
+
+	query := `SELECT ` + count + `, ` + listingColumns + `, (` + key.expr + `)::text
 			  FROM listings l
 			  INNER JOIN users u ON u.id = l.owner_id
-			  WHERE l.owner_id = $1`
+			  WHERE ` + strings.Join(conditions, ` AND `) + `
+			  ORDER BY ` + orderBy + ` ` + limit
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	rows, err := m.DB.QueryContext(ctx, query, userID)
+	rows, err := m.DB.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, Metadata{}, cursorError(filters, err)
 	}
 
 	defer rows.Close()
 
-	var listings []*Listing
+	totalRecords := 0
+	var read []pageRow[*Listing]
+
 	for rows.Next() {
 		var listing Listing
-		err := rows.Scan(
-			&listing.ID,
-			&listing.CreatedAt,
-			&listing.Title,
-			&listing.Description,
-			&listing.Category,
-			&listing.Bedrooms,
-			&listing.Bathrooms,
-			&listing.Guests,
-			&listing.Location.Flag,
-			&listing.Location.Label,
-			&listing.Location.Lat,
-			&listing.Location.Lng,
-			&listing.Location.Region,
-			&listing.Location.Value,
-			&listing.Price,
-			&listing.OwnerID,
-			&listing.OwnerName,
-			&listing.OwnerPhoto,
-		)
+		var sortKey string
+
+		dest := append([]interface{}{&totalRecords}, listing.scanDest()...)
+		err := rows.Scan(append(dest, &sortKey)...)
 		if err != nil {
-			return nil, err
+			return nil, Metadata{}, err
 		}
 
-		listings = append(listings, &listing)
+		read = append(read, pageRow[*Listing]{row: &listing, key: sortKey, id: listing.ID})
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, err
+		return nil, Metadata{}, cursorError(filters, err)
 	}
 
-	return listings, nil
+	listings, metadata := page(filters, totalRecords, read)
+
+	return listings, metadata, nil
 }
 
 func (m ListingsModel) Delete(id, ownerId int64) error {
@@ -221,86 +392,93 @@ func (m ListingsModel) Delete(id, ownerId int64) error {
 	return nil
 }
 
-func (m ListingsModel) GetAll(search string, filters Filters) ([]*Listing, Metadata, error) {
-	baseQuery := `SELECT count(*) OVER(), l.id, l.created_at, l.title, l.description, l.category, l.bedrooms,
-				 l.bathrooms, l.guests, l.location_flag, l.location_label, l.location_lat, l.location_lng,
-				 l.location_region, l.location_value, l.price, l.owner_id, u.name, COALESCE(u.image, '')
-				 FROM listings l INNER JOIN users u ON u.id = l.owner_id`
-
-	if search != "" {
-		baseQuery += ` WHERE (l.title ILIKE '%' || $3 || '%'
- 					   OR l.category ILIKE '%' || $3 || '%'
-					   OR l.location_region ILIKE '%' || $3 || '%'
-					   OR l.location_label ILIKE '%' || $3 || '%')`
+// GetAll returns a page of listings matching search and filters. Every listing carries its distance
+// from the search's origin when the search has a location, and highlights when it has text.
+func (m ListingsModel) GetAll(search ListingSearch, filters Filters) ([]*Listing, Metadata, error) {
+	var args queryArgs
+	q := search.sql(&args)
+	conditions := append(q.conditions, filters.ListingFilters.where(&args)...)
+
+	key := filters.sortKey(map[string]sortKey{
+		"id":         {"l.id", "bigint"},
+		"created_at": {"l.created_at", "timestamp"},
+		"title":      {"l.title", "text"},
+		"price":      {"l.price", "integer"},
+		"distance":   {q.distance, "double precision"},
+		"relevance":  {q.relevance, "real"},
+	})
+	after, orderBy, limit := filters.pageSQL(&args, key, "l.id")
+	if after != "" {
+		conditions = append(conditions, after)
 	}
 
-	advQuery := fmt.Sprintf(` ORDER BY %s %s `, filters.sortColumn(), filters.sortDirection())
-
-	query := baseQuery + advQuery + ` LIMIT $1 OFFSET $2`
-
-	args := []interface{}{filters.limit(), filters.offset()}
+	// Counting every match is what makes deep pages slow, so cursor pages skip it.
+	count := "count(*) OVER()"
+	if filters.Cursor != nil {
+		count = "0"
+	}
 
-	if search != "" {
-		args = append(args, search)
+	// Postgres computes the headlines after sorting and limiting, so only the returned page pays for them.
+	query := `SELECT ` + count + `, ` + listingColumns + `, ` + q.distance + ` AS distance,
+			  ` + q.relevance + ` AS relevance, ` + q.titleHeadline + `, ` + q.descriptionHeadline + `,
+			  (` + key.expr + `)::text
+			  FROM listings l INNER JOIN users u ON u.id = l.owner_id`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, ` AND `)
 	}
+	query += ` ORDER BY ` + orderBy + ` ` + limit
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
 	rows, err := m.DB.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, Metadata{}, err
+		return nil, Metadata{}, cursorError(filters, err)
 	}
 	defer rows.Close()
 
 	totalRecords := 0
-	var listings []*Listing
+	var read []pageRow[*Listing]
 
 	for rows.Next() {
 		var listing Listing
+		var relevance *float64
+		var title, description *string
+		var sortKey string
 
-		err := rows.Scan(
-			&totalRecords,
-			&listing.ID,
-			&listing.CreatedAt,
-			&listing.Title,
-			&listing.Description,
-			&listing.Category,
-			&listing.Bedrooms,
-			&listing.Bathrooms,
-			&listing.Guests,
-			&listing.Location.Flag,
-			&listing.Location.Label,
-			&listing.Location.Lat,
-			&listing.Location.Lng,
-			&listing.Location.Region,
-			&listing.Location.Value,
-			&listing.Price,
-			&listing.OwnerID,
-			&listing.OwnerName,
-			&listing.OwnerPhoto,
-		)
-		if err = rows.Err(); err != nil {
+		dest := append([]interface{}{&totalRecords}, listing.scanDest()...)
+		err := rows.Scan(append(dest, &listing.DistanceKm, &relevance, &title, &description, &sortKey)...)
+		if err != nil {
 			return nil, Metadata{}, err
 		}
 
-		listings = append(listings, &listing)
+		if title != nil && description != nil {
+			listing.Highlights = &ListingHighlights{Title: highlight(*title), Description: highlight(*description)}
+		}
+
+		read = append(read, pageRow[*Listing]{row: &listing, key: sortKey, id: listing.ID})
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, Metadata{}, err
+		return nil, Metadata{}, cursorError(filters, err)
 	}
 
-	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+	listings, metadata := page(filters, totalRecords, read)
 
 	return listings, metadata, nil
 }
 
+// Update saves the listing. Its amenities are replaced by listing.Amenities unless that is nil. Its
+// status is changed with UpdateStatus. It fails with ErrEditConflict if the listing was saved since
+// listing.Version was read, and bumps listing.Version otherwise.
 func (m ListingsModel) Update(listing *Listing) error {
-	query := `UPDATE listings SET title = $1, description = $2, category = $3, bedrooms = $4,
+	query := `UPDATE listings SET title = $1, description = $2, category = NULLIF($3, ''), bedrooms = $4,
 			  bathrooms = $5, guests = $6, location_flag = $7, location_label = $8, location_lat = $9,
-			  location_lng = $10, location_region = $11, location_value = $12, price = $13
-			  WHERE id = $14`
+			  location_lng = $10, location_region = $11, location_value = $12, price = $13,
+			  cleaning_fee = $14, service_fee_percent = $15, instant_book = $16, request_expiry_hours = $17,
+			  max_pets = $18, base_occupancy = $19, extra_guest_fee = $20, version = version + 1
+			  WHERE id = $21 AND version = $22
+			  RETURNING version`
 
 	args := []interface{}{
 		listing.Title,
@@ -316,24 +494,103 @@ func (m ListingsModel) Update(listing *Listing) error {
 		listing.Location.Region,
 		listing.Location.Value,
 		listing.Price,
+		listing.CleaningFee,
+		listing.ServiceFee,
+		listing.InstantBook,
+		listing.RequestExpiryHours,
+		listing.MaxPets,
+		listing.BaseOccupancy,
+		listing.ExtraGuestFee,
 		listing.ID,
+		listing.Version,
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	result, err := m.DB.ExecContext(ctx, query, args...)
+	tx, err := m.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&listing.Version)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return listingMissingOrChanged(ctx, tx, listing.ID)
+		default:
+			return listingCategoryError(err)
+		}
+	}
+
+	if listing.Amenities != nil {
+		err = setListingAmenities(ctx, tx, listing.ID, listing.Amenities)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// UpdateStatus saves the status set by Listing.Transition. It fails with ErrEditConflict if the
+// listing is no longer in status from.
+func (m ListingsModel) UpdateStatus(listing *Listing, from string) error {
+	query := `UPDATE listings SET status = $1, published_at = $2, version = version + 1
+			  WHERE id = $3 AND status = $4
+			  RETURNING version`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, listing.Status, listing.PublishedAt, listing.ID, from).Scan(&listing.Version)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrEditConflict
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UpdateServiceFee sets the listing's service fee and returns the listing as saved. It is kept apart
+// from Update because the fee is the platform's to set, not the host's.
+func (m ListingsModel) UpdateServiceFee(id, percent int64) (*Listing, error) {
+	query := `UPDATE listings SET service_fee_percent = $1, version = version + 1 WHERE id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, percent, id)
+	if err != nil {
+		return nil, err
+	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if rowsAffected == 0 {
-		return ErrRecordNotFound
+		return nil, ErrRecordNotFound
 	}
 
-	return nil
+	return m.Get(id)
+}
+
+// listingMissingOrChanged tells apart the two reasons an update matched no row: the listing is gone,
+// or someone saved it since it was read.
+func listingMissingOrChanged(ctx context.Context, tx *sql.Tx, id int64) error {
+	var exists bool
+	err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM listings WHERE id = $1)`, id).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrRecordNotFound
+	}
+	return ErrEditConflict
 }
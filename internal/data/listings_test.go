@@ -2,8 +2,10 @@ package data
 
 import (
 	"github.com/air-bnb/internal/random"
+	"github.com/air-bnb/internal/validator"
 	"github.com/stretchr/testify/require"
 	"testing"
+	"time"
 )
 
 func TestListingsModel_Insert(t *testing.T) {
@@ -38,7 +40,7 @@ func TestListingsModel_AllUserListings(t *testing.T) {
 	CreateRandomListing(t, user)
 	CreateRandomListing(t, user)
 
-	listings, err := testQueries.Listings.AllUserListings(user.ID)
+	listings, _, err := testQueries.Listings.AllUserListings(user.ID, Filters{Sort: "id", SortSafelist: []string{"id"}})
 	require.NoError(t, err)
 	require.NotEmpty(t, listings)
 	require.Len(t, listings, 3)
@@ -50,7 +52,7 @@ func TestListingsModel_AllUserListings_Empty(t *testing.T) {
 	CreateRandomListing(t, user)
 	CreateRandomListing(t, user)
 
-	listings, err := testQueries.Listings.AllUserListings(user.ID + 1)
+	listings, _, err := testQueries.Listings.AllUserListings(user.ID+1, Filters{Sort: "id", SortSafelist: []string{"id"}})
 	require.NoError(t, err)
 	require.Empty(t, listings)
 }
@@ -119,8 +121,293 @@ func TestListingsModel_GetAll(t *testing.T) {
 	filters.SortSafelist = []string{"id", "-id"}
 	filters.Sort = "id"
 
-	listings, _, err := testQueries.Listings.GetAll("", filters)
+	listings, _, err := testQueries.Listings.GetAll(ListingSearch{}, filters)
 	require.NoError(t, err)
 	require.NotEmpty(t, listings)
 	require.Len(t, listings, 10)
 }
+
+func TestListingsModel_GetAll_Filters(t *testing.T) {
+	user := CreateRandomUser(t)
+	region := random.RandString(8)
+	checkIn := truncateDate(time.Now().AddDate(0, 0, 350))
+
+	insert := func(category string, price, bedrooms int64) Listing {
+		listing := CreateRandomListing(t, user)
+		listing.Category = category
+		listing.Price = price
+		listing.Bedrooms = bedrooms
+		listing.Location.Region = region
+		require.NoError(t, testQueries.Listings.Update(&listing))
+		return listing
+	}
+	cheapBeach := insert("beach", 40, 1)
+	beach := insert("beach", 90, 3)
+	cabin := insert("barns", 120, 3)
+	insert("castles", 90, 3)
+
+	booked := &Booking{ListingID: beach.ID, GuestID: user.ID, CheckIn: checkIn, CheckOut: checkIn.AddDate(0, 0, 2), Price: 90, Total: 180}
+	require.NoError(t, testQueries.Bookings.Insert(booked))
+
+	search := func(f ListingFilters) []int64 {
+		filters := Filters{Page: 1, PageSize: 10, Sort: "price", SortSafelist: []string{"price"}, ListingFilters: f}
+		filters.Region = region
+
+		listings, _, err := testQueries.Listings.GetAll(ListingSearch{}, filters)
+		require.NoError(t, err)
+
+		var ids []int64
+		for _, listing := range listings {
+			ids = append(ids, listing.ID)
+		}
+		return ids
+	}
+
+	require.Equal(t, []int64{cheapBeach.ID, beach.ID, cabin.ID}, search(ListingFilters{Categories: []string{"beach", "barns"}}))
+	require.Equal(t, []int64{beach.ID, cabin.ID}, search(ListingFilters{Categories: []string{"beach", "barns"}, MinBedrooms: 2}))
+	require.Equal(t, []int64{cheapBeach.ID}, search(ListingFilters{Categories: []string{"beach"}, MaxPrice: 50}))
+	require.Equal(t, []int64{cheapBeach.ID, cabin.ID}, search(ListingFilters{
+		Categories: []string{"beach", "barns"},
+		CheckIn:    checkIn.AddDate(0, 0, 1),
+		CheckOut:   checkIn.AddDate(0, 0, 4),
+	}))
+}
+
+func TestListingsModel_Facets(t *testing.T) {
+	user := CreateRandomUser(t)
+	region := random.RandString(8)
+	category := CreateRandomCategory(t).Slug
+
+	insert := func(category string, price, bedrooms int64) {
+		listing := CreateRandomListing(t, user)
+		listing.Category = category
+		listing.Price = price
+		listing.Bedrooms = bedrooms
+		listing.Location.Region = region
+		require.NoError(t, testQueries.Listings.Update(&listing))
+	}
+	insert(category, 40, 1)
+	insert(category, 60, 2)
+	insert(category, 2000, 9)
+	insert("barns", 45, 2)
+
+	facets, err := testQueries.Listings.Facets(ListingSearch{}, ListingFilters{Region: region})
+	require.NoError(t, err)
+
+	require.Equal(t, []FacetCount{{Value: category, Count: 3}, {Value: "barns", Count: 1}}, facets.Categories)
+	require.Equal(t, []FacetCount{{Value: region, Count: 4}}, facets.Regions)
+
+	require.Len(t, facets.Bedrooms, BedroomFacetBuckets)
+	require.Equal(t, 0, facets.Bedrooms[0].Count)
+	require.Equal(t, 1, facets.Bedrooms[1].Count)
+	require.Equal(t, 2, facets.Bedrooms[2].Count)
+	require.Equal(t, 1, facets.Bedrooms[BedroomFacetBuckets-1].Count)
+	require.Nil(t, facets.Bedrooms[BedroomFacetBuckets-1].Max)
+
+	require.Len(t, facets.Prices, PriceFacetBuckets)
+	require.Equal(t, int64(0), facets.Prices[0].Min)
+	require.Equal(t, int64(PriceFacetWidth-1), *facets.Prices[0].Max)
+	require.Equal(t, 2, facets.Prices[0].Count)
+	require.Equal(t, 1, facets.Prices[1].Count)
+	require.Equal(t, 1, facets.Prices[PriceFacetBuckets-1].Count)
+
+	// Facets are counted under the same filters as the results.
+	facets, err = testQueries.Listings.Facets(ListingSearch{}, ListingFilters{Region: region, MaxPrice: 50})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []FacetCount{{Value: category, Count: 1}, {Value: "barns", Count: 1}}, facets.Categories)
+	require.Equal(t, 2, facets.Prices[0].Count)
+	require.Equal(t, 0, facets.Prices[1].Count)
+}
+
+func TestListingsModel_GetAll_Cursor(t *testing.T) {
+	user := CreateRandomUser(t)
+	region := random.RandString(8)
+
+	insert := func(price int64) int64 {
+		listing := CreateRandomListing(t, user)
+		listing.Price = price
+		listing.Location.Region = region
+		require.NoError(t, testQueries.Listings.Update(&listing))
+		return listing.ID
+	}
+	// Equal prices are ordered by id, in the same direction as the sort.
+	a, b, c, d, e := insert(30), insert(20), insert(20), insert(10), insert(20)
+	want := []int64{a, e, c, b, d}
+
+	filters := Filters{Page: 1, PageSize: 2, Sort: "-price", SortSafelist: []string{"-price"}}
+	filters.Region = region
+
+	get := func(cursor *Cursor) ([]int64, Metadata) {
+		f := filters
+		f.Cursor = cursor
+		listings, metadata, err := testQueries.Listings.GetAll(ListingSearch{}, f)
+		require.NoError(t, err)
+
+		var ids []int64
+		for _, listing := range listings {
+			ids = append(ids, listing.ID)
+		}
+		return ids, metadata
+	}
+	decode := func(token string) *Cursor {
+		cursor, err := DecodeCursor(token)
+		require.NoError(t, err)
+		return cursor
+	}
+
+	ids, metadata := get(nil)
+	require.Equal(t, want[:2], ids)
+	require.Equal(t, 5, metadata.TotalRecords)
+	require.Empty(t, metadata.Prev)
+
+	// A listing added mid-scroll before the cursor doesn't shift the following pages.
+	insert(40)
+
+	ids, metadata = get(decode(metadata.Next))
+	require.Equal(t, want[2:4], ids)
+	require.Zero(t, metadata.TotalRecords)
+	require.NotEmpty(t, metadata.Prev)
+
+	last, lastMetadata := get(decode(metadata.Next))
+	require.Equal(t, want[4:], last)
+	require.Empty(t, lastMetadata.Next)
+
+	ids, metadata = get(decode(lastMetadata.Prev))
+	require.Equal(t, want[2:4], ids)
+	require.NotEmpty(t, metadata.Next)
+
+	_, _, err := testQueries.Listings.GetAll(ListingSearch{}, Filters{Page: 1, PageSize: 2, Sort: "-price",
+		SortSafelist: []string{"-price"}, Cursor: &Cursor{Sort: "-price", Key: "not a price", ID: 1}})
+	require.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestListingsModel_AllUserListings_Paged(t *testing.T) {
+	user := CreateRandomUser(t)
+	var want []int64
+	for i := 0; i < 3; i++ {
+		want = append(want, CreateRandomListing(t, user).ID)
+	}
+
+	filters := Filters{Page: 1, PageSize: 2, Sort: "id", SortSafelist: []string{"id"}}
+	listings, metadata, err := testQueries.Listings.AllUserListings(user.ID, filters)
+	require.NoError(t, err)
+	require.Len(t, listings, 2)
+	require.Equal(t, 3, metadata.TotalRecords)
+
+	filters.Cursor, err = DecodeCursor(metadata.Next)
+	require.NoError(t, err)
+	listings, metadata, err = testQueries.Listings.AllUserListings(user.ID, filters)
+	require.NoError(t, err)
+	require.Len(t, listings, 1)
+	require.Equal(t, want[2], listings[0].ID)
+	require.Empty(t, metadata.Next)
+}
+
+func TestListing_Transition(t *testing.T) {
+	listing := &Listing{Status: ListingDraft}
+
+	err := listing.Transition(ListingPublished)
+	require.ErrorIs(t, err, ErrInvalidListingTransition)
+	require.Equal(t, ListingDraft, listing.Status)
+
+	require.NoError(t, listing.Transition(ListingPendingReview))
+	require.Nil(t, listing.PublishedAt)
+
+	require.NoError(t, listing.Transition(ListingPublished))
+	require.NotNil(t, listing.PublishedAt)
+	publishedAt := *listing.PublishedAt
+
+	require.NoError(t, listing.Transition(ListingUnlisted))
+	require.NoError(t, listing.Transition(ListingPublished))
+	require.Equal(t, publishedAt, *listing.PublishedAt)
+
+	require.NoError(t, listing.Transition(ListingArchived))
+	require.False(t, listing.CanTransition(ListingPublished))
+	require.True(t, listing.CanTransition(ListingDraft))
+}
+
+func TestValidateListingComplete(t *testing.T) {
+	v := validator.New()
+	ValidateListingComplete(v, &Listing{Title: "Cabin"}, 0)
+	require.False(t, v.Valid())
+	for _, key := range []string{"description", "category", "location.flag", "location.lat", "location.lng", "price", "images"} {
+		require.Contains(t, v.Errors, key)
+	}
+	require.NotContains(t, v.Errors, "title")
+
+	// The equator and the prime meridian are places like any other.
+	lat, lng := 0.0, 0.0
+	listing := &Listing{
+		Title:       "Cabin",
+		Description: "By the lake",
+		Category:    "beach",
+		Location:    Location{Flag: "HR", Lat: &lat, Lng: &lng},
+		Price:       80,
+	}
+	v = validator.New()
+	ValidateListingComplete(v, listing, MinListingImages)
+	require.True(t, v.Valid())
+}
+
+func TestListingsModel_GetAll_PublishedOnly(t *testing.T) {
+	user := CreateRandomUser(t)
+	published := CreateRandomListing(t, user)
+	region := published.Location.Region
+
+	draft := Listing{OwnerID: user.ID, Title: random.RandString(10), Bedrooms: 1, Bathrooms: 1, Guests: 1}
+	draft.Location.Region = region
+	require.NoError(t, testQueries.Listings.Insert(&draft))
+	require.Equal(t, ListingDraft, draft.Status)
+	require.Nil(t, draft.PublishedAt)
+
+	filters := Filters{Page: 1, PageSize: 10, Sort: "id", SortSafelist: []string{"id"}}
+	filters.Region = region
+	listings, _, err := testQueries.Listings.GetAll(ListingSearch{}, filters)
+	require.NoError(t, err)
+	require.Len(t, listings, 1)
+	require.Equal(t, published.ID, listings[0].ID)
+
+	fromDB, err := testQueries.Listings.Get(draft.ID)
+	require.NoError(t, err)
+	require.Equal(t, ListingDraft, fromDB.Status)
+	require.Empty(t, fromDB.Category)
+}
+
+func TestListingsModel_UpdateStatus(t *testing.T) {
+	user := CreateRandomUser(t)
+	listing := CreateRandomListing(t, user)
+
+	require.NoError(t, listing.Transition(ListingUnlisted))
+	require.NoError(t, testQueries.Listings.UpdateStatus(&listing, ListingPublished))
+
+	fromDB, err := testQueries.Listings.Get(listing.ID)
+	require.NoError(t, err)
+	require.Equal(t, ListingUnlisted, fromDB.Status)
+
+	stale := listing
+	require.NoError(t, stale.Transition(ListingArchived))
+	err = testQueries.Listings.UpdateStatus(&stale, ListingPublished)
+	require.ErrorIs(t, err, ErrEditConflict)
+}
+
+func TestListingsModel_Update_Version(t *testing.T) {
+	user := CreateRandomUser(t)
+	listing := CreateRandomListing(t, user)
+	require.EqualValues(t, 1, listing.Version)
+
+	stale := listing
+
+	listing.Title = random.RandString(8)
+	require.NoError(t, testQueries.Listings.Update(&listing))
+	require.EqualValues(t, 2, listing.Version)
+
+	stale.Description = random.RandString(8)
+	err := testQueries.Listings.Update(&stale)
+	require.ErrorIs(t, err, ErrEditConflict)
+
+	fromDB, err := testQueries.Listings.Get(listing.ID)
+	require.NoError(t, err)
+	require.Equal(t, listing.Title, fromDB.Title)
+	require.Equal(t, listing.Description, fromDB.Description)
+	require.Equal(t, listing.Version, fromDB.Version)
+}
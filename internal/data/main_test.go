@@ -66,10 +66,11 @@ func CreateTokenForUser(t *testing.T, user User) Token {
 }
 
 func CreateRandomListing(t *testing.T, user User) Listing {
+	lat, lng := 23.5, 23.5
 	location := Location{
 		Flag:   "HR",
-		Lat:    23.5,
-		Lng:    23.5,
+		Lat:    &lat,
+		Lng:    &lng,
 		Label:  random.RandString(5),
 		Region: random.RandString(5),
 		Value:  random.RandString(5),
@@ -79,12 +80,13 @@ func CreateRandomListing(t *testing.T, user User) Listing {
 		OwnerName:   user.Name,
 		Title:       random.RandString(10),
 		Description: random.RandString(10),
-		Category:    random.RandString(10),
+		Category:    "beach",
 		Price:       random.RandInt(1, 100),
 		Guests:      random.RandInt(1, 10),
 		Bedrooms:    random.RandInt(1, 10),
 		Bathrooms:   random.RandInt(1, 10),
 		Location:    location,
+		Status:      ListingPublished,
 	}
 
 	err := testQueries.Listings.Insert(&listing)
@@ -13,20 +13,52 @@ var (
 )
 
 type Models struct {
-	Users    UserModel
-	Tokens   TokenModel
-	Listings ListingsModel
-	Images   ImageModel
-	Bookings BookingModel
+	Users        UserModel
+	Tokens       TokenModel
+	Listings     ListingsModel
+	Images       ImageModel
+	Bookings     BookingModel
+	Quotes       QuoteModel
+	Blocks       BlockedDateModel
+	Availability AvailabilityModel
+	StayRules    StayRuleModel
+	PricingRules PricingRuleModel
+	TaxRules     TaxRuleModel
+	LineItems    LineItemModel
+	Payments     PaymentModel
+	Cancellation CancellationPolicyModel
+	ListingHosts ListingHostModel
+	Changes      BookingChangeModel
+	Holds        HoldModel
+	Calendars    CalendarFeedModel
+	Imports      CalendarImportModel
+	Amenities    AmenityModel
+	Categories   CategoryModel
 }
 
 func NewModels(db *sql.DB) Models {
 	return Models{
-		Users:    UserModel{DB: db},
-		Tokens:   TokenModel{DB: db},
-		Listings: ListingsModel{DB: db},
-		Images:   ImageModel{DB: db},
-		Bookings: BookingModel{DB: db},
+		Users:        UserModel{DB: db},
+		Tokens:       TokenModel{DB: db},
+		Listings:     ListingsModel{DB: db},
+		Images:       ImageModel{DB: db},
+		Bookings:     BookingModel{DB: db},
+		Quotes:       QuoteModel{DB: db},
+		Blocks:       BlockedDateModel{DB: db},
+		Availability: AvailabilityModel{DB: db},
+		StayRules:    StayRuleModel{DB: db},
+		PricingRules: PricingRuleModel{DB: db},
+		TaxRules:     TaxRuleModel{DB: db},
+		LineItems:    LineItemModel{DB: db},
+		Payments:     PaymentModel{DB: db},
+		Cancellation: CancellationPolicyModel{DB: db},
+		ListingHosts: ListingHostModel{DB: db},
+		Changes:      BookingChangeModel{DB: db},
+		Holds:        HoldModel{DB: db},
+		Calendars:    CalendarFeedModel{DB: db},
+		Imports:      CalendarImportModel{DB: db},
+		Amenities:    AmenityModel{DB: db},
+		Categories:   CategoryModel{DB: db},
 	}
 }
 
@@ -72,3 +104,27 @@ func LoadPgFloatArray(arr string) *[]float64 {
 	}
 	return &floatArr
 }
+
+func SavePgIntArray(arr []int64) string {
+	var strArr []string
+	for _, v := range arr {
+		strArr = append(strArr, strconv.FormatInt(v, 10))
+	}
+	return "{" + strings.Join(strArr, ",") + "}"
+}
+
+func LoadPgIntArray(arr string) []int64 {
+	intArr := []int64{}
+	arr = strings.Trim(arr, "{}")
+	if arr == "" {
+		return intArr
+	}
+	for _, v := range strings.Split(arr, ",") {
+		i, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil
+		}
+		intArr = append(intArr, i)
+	}
+	return intArr
+}
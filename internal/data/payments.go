@@ -0,0 +1,98 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+const (
+	PaymentAuthorized = "authorized"
+	PaymentCaptured   = "captured"
+	PaymentRefunded   = "refunded"
+	PaymentVoided     = "voided"
+)
+
+type PaymentModel struct {
+	DB *sql.DB
+}
+
+// Payment records the money held or taken for a booking. Reference is the provider's identifier.
+type Payment struct {
+	ID        int64  `json:"id"`
+	CreatedAt string `json:"createdAt"`
+	BookingID int64  `json:"bookingId"`
+	Provider  string `json:"provider"`
+	Reference string `json:"-"`
+	Status    string `json:"status"`
+	Amount    int64  `json:"amount"`
+	Captured  int64  `json:"capturedAmount"`
+	Refunded  int64  `json:"refundedAmount"`
+}
+
+// RefundDue is how much of a captured payment still has to go back to the guest for a cancelled
+// booking, capped at what was captured and not yet refunded.
+func RefundDue(booking *Booking, payment *Payment) int64 {
+	return min(booking.RefundAmount, payment.Captured-payment.Refunded)
+}
+
+func (m PaymentModel) Insert(payment *Payment) error {
+	query := `INSERT INTO payments (booking_id, provider, reference, status, amount)
+			  VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at`
+
+	args := []interface{}{payment.BookingID, payment.Provider, payment.Reference, payment.Status, payment.Amount}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.DB.QueryRowContext(ctx, query, args...).Scan(&payment.ID, &payment.CreatedAt)
+}
+
+func (m PaymentModel) GetForBooking(bookingID int64) (*Payment, error) {
+	query := `SELECT id, created_at, booking_id, provider, reference, status, amount, captured_amount, refunded_amount
+			  FROM payments
+			  WHERE booking_id = $1`
+
+	var payment Payment
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, bookingID).Scan(
+		&payment.ID,
+		&payment.CreatedAt,
+		&payment.BookingID,
+		&payment.Provider,
+		&payment.Reference,
+		&payment.Status,
+		&payment.Amount,
+		&payment.Captured,
+		&payment.Refunded,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &payment, nil
+}
+
+// Update saves the payment's state. A booking change can move it to a new reference and amount.
+func (m PaymentModel) Update(payment *Payment) error {
+	query := `UPDATE payments SET reference = $1, status = $2, amount = $3, captured_amount = $4,
+			  refunded_amount = $5, updated_at = NOW()
+			  WHERE id = $6`
+
+	args := []interface{}{payment.Reference, payment.Status, payment.Amount, payment.Captured, payment.Refunded, payment.ID}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, query, args...)
+	return err
+}
@@ -0,0 +1,52 @@
+package data
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestRefundDue(t *testing.T) {
+	payment := &Payment{Amount: 300, Captured: 300, Refunded: 50}
+
+	require.Equal(t, int64(150), RefundDue(&Booking{RefundAmount: 150}, payment))
+	require.Equal(t, int64(250), RefundDue(&Booking{RefundAmount: 300}, payment))
+	require.Equal(t, int64(0), RefundDue(&Booking{}, payment))
+}
+
+func TestPaymentModel(t *testing.T) {
+	user := CreateRandomUser(t)
+	listing := CreateRandomListing(t, user)
+	checkIn := time.Now().AddDate(0, 0, 250)
+	booking := &Booking{
+		ListingID: listing.ID,
+		GuestID:   user.ID,
+		CheckIn:   checkIn,
+		CheckOut:  checkIn.AddDate(0, 0, 2),
+		Price:     listing.Price,
+		Total:     listing.Price * 2,
+	}
+	require.NoError(t, testQueries.Bookings.Insert(booking))
+
+	payment := &Payment{
+		BookingID: booking.ID,
+		Provider:  "fake",
+		Reference: "fake_test",
+		Status:    PaymentAuthorized,
+		Amount:    booking.Total,
+	}
+	require.NoError(t, testQueries.Payments.Insert(payment))
+	require.NotZero(t, payment.ID)
+
+	payment.Status = PaymentCaptured
+	payment.Captured = booking.Total
+	require.NoError(t, testQueries.Payments.Update(payment))
+
+	stored, err := testQueries.Payments.GetForBooking(booking.ID)
+	require.NoError(t, err)
+	require.Equal(t, PaymentCaptured, stored.Status)
+	require.Equal(t, booking.Total, stored.Captured)
+
+	_, err = testQueries.Payments.GetForBooking(-1)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
@@ -0,0 +1,175 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"github.com/air-bnb/internal/validator"
+	"time"
+)
+
+const (
+	// PricingSeason replaces the nightly price for every night in [StartDate, EndDate).
+	PricingSeason = "season"
+	// PricingWeekday replaces the nightly price on the given Weekdays.
+	PricingWeekday = "weekday"
+	// PricingWeekend adds Percent to the nightly price on the given Weekdays (Friday and Saturday by default).
+	PricingWeekend = "weekend"
+	// PricingLengthOfStay takes Percent off the subtotal of stays of at least MinNights.
+	PricingLengthOfStay = "length_of_stay"
+)
+
+var PricingRuleKinds = []string{PricingSeason, PricingWeekday, PricingWeekend, PricingLengthOfStay}
+
+var defaultWeekendDays = []int64{int64(time.Friday), int64(time.Saturday)}
+
+type PricingRuleModel struct {
+	DB *sql.DB
+}
+
+type PricingRule struct {
+	ID        int64      `json:"id"`
+	CreatedAt string     `json:"createdAt"`
+	ListingID int64      `json:"listingId"`
+	Kind      string     `json:"kind"`
+	StartDate *time.Time `json:"startDate,omitempty"`
+	EndDate   *time.Time `json:"endDate,omitempty"`
+	Weekdays  []int64    `json:"weekdays"`
+	Price     int64      `json:"price,omitempty"`
+	Percent   int64      `json:"percent,omitempty"`
+	MinNights int64      `json:"minNights,omitempty"`
+}
+
+func (p *PricingRule) covers(night time.Time) bool {
+	switch p.Kind {
+	case PricingSeason:
+		return !night.Before(truncateDate(*p.StartDate)) && night.Before(truncateDate(*p.EndDate))
+	case PricingWeekday, PricingWeekend:
+		return validator.PermittedValue(int64(night.Weekday()), p.Weekdays...)
+	}
+	return false
+}
+
+func ValidatePricingRule(v *validator.Validator, rule *PricingRule) {
+	v.Check(validator.PermittedValue(rule.Kind, PricingRuleKinds...), "kind", "must be one of season, weekday, weekend or length_of_stay")
+
+	for _, day := range rule.Weekdays {
+		v.Check(day >= 0 && day <= 6, "weekdays", "must only contain values between 0 (Sunday) and 6 (Saturday)")
+	}
+	v.Check(validator.Unique(rule.Weekdays), "weekdays", "must not contain duplicate values")
+
+	switch rule.Kind {
+	case PricingSeason:
+		v.Check(rule.StartDate != nil, "startDate", "must be provided")
+		v.Check(rule.EndDate != nil, "endDate", "must be provided")
+		if rule.StartDate != nil && rule.EndDate != nil {
+			v.Check(rule.EndDate.After(*rule.StartDate), "endDate", "must be after startDate")
+		}
+		v.Check(rule.Price > 0, "price", "must be greater than zero")
+	case PricingWeekday:
+		v.Check(len(rule.Weekdays) > 0, "weekdays", "must contain at least one weekday")
+		v.Check(rule.Price > 0, "price", "must be greater than zero")
+	case PricingWeekend:
+		v.Check(rule.Percent > 0, "percent", "must be greater than zero")
+		v.Check(rule.Percent <= 500, "percent", "must not be more than 500")
+	case PricingLengthOfStay:
+		v.Check(rule.MinNights >= 2, "minNights", "must be at least 2")
+		v.Check(rule.Percent > 0, "percent", "must be greater than zero")
+		v.Check(rule.Percent < 100, "percent", "must be less than 100")
+	}
+}
+
+func (m PricingRuleModel) Insert(rule *PricingRule) error {
+	if rule.Kind == PricingWeekend && len(rule.Weekdays) == 0 {
+		rule.Weekdays = defaultWeekendDays
+	}
+	if rule.Weekdays == nil {
+		rule.Weekdays = []int64{}
+	}
+
+	query := `INSERT INTO pricing_rules (listing_id, kind, start_date, end_date, weekdays, price, percent, min_nights)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, created_at`
+
+	args := []interface{}{
+		rule.ListingID,
+		rule.Kind,
+		rule.StartDate,
+		rule.EndDate,
+		SavePgIntArray(rule.Weekdays),
+		rule.Price,
+		rule.Percent,
+		rule.MinNights,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.DB.QueryRowContext(ctx, query, args...).Scan(&rule.ID, &rule.CreatedAt)
+}
+
+// GetForListing returns the listing's rules in creation order; later rules win when they overlap.
+func (m PricingRuleModel) GetForListing(listingID int64) ([]*PricingRule, error) {
+	query := `SELECT id, created_at, listing_id, kind, start_date, end_date, weekdays, price, percent, min_nights
+			  FROM pricing_rules
+			  WHERE listing_id = $1
+			  ORDER BY id`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, listingID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []*PricingRule
+	for rows.Next() {
+		var rule PricingRule
+		var weekdays string
+		err := rows.Scan(
+			&rule.ID,
+			&rule.CreatedAt,
+			&rule.ListingID,
+			&rule.Kind,
+			&rule.StartDate,
+			&rule.EndDate,
+			&weekdays,
+			&rule.Price,
+			&rule.Percent,
+			&rule.MinNights,
+		)
+		if err != nil {
+			return nil, err
+		}
+		rule.Weekdays = LoadPgIntArray(weekdays)
+		rules = append(rules, &rule)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+func (m PricingRuleModel) Delete(id, listingID int64) error {
+	query := `DELETE FROM pricing_rules WHERE id = $1 AND listing_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id, listingID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
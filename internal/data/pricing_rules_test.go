@@ -0,0 +1,100 @@
+package data
+
+import (
+	"github.com/air-bnb/internal/validator"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestBuildQuote_PricingRules(t *testing.T) {
+	// Thursday 27 June to Thursday 4 July: the season starts on 1 July.
+	checkIn := time.Date(2024, time.June, 27, 0, 0, 0, 0, time.UTC)
+	seasonStart := time.Date(2024, time.July, 1, 0, 0, 0, 0, time.UTC)
+	seasonEnd := time.Date(2024, time.September, 1, 0, 0, 0, 0, time.UTC)
+
+	rules := []*PricingRule{
+		{Kind: PricingWeekday, Weekdays: []int64{int64(time.Sunday)}, Price: 80},
+		{Kind: PricingSeason, StartDate: &seasonStart, EndDate: &seasonEnd, Price: 200},
+		{Kind: PricingWeekend, Weekdays: defaultWeekendDays, Percent: 50},
+		{Kind: PricingLengthOfStay, MinNights: 7, Percent: 10},
+		{Kind: PricingLengthOfStay, MinNights: 28, Percent: 25},
+	}
+
+	quote := buildQuote(QuoteRequest{CheckIn: checkIn, CheckOut: checkIn.AddDate(0, 0, 7), Guests: 1}, quotePricing{price: 100, rules: rules})
+
+	prices := make([]int64, 0, len(quote.Nights))
+	for _, night := range quote.Nights {
+		prices = append(prices, night.Price)
+	}
+	// Thu, Fri (+50%), Sat (+50%), Sun (weekday price), Mon-Wed (season).
+	require.Equal(t, []int64{100, 150, 150, 80, 200, 200, 200}, prices)
+	require.Equal(t, []string{PricingWeekday}, quote.Nights[3].Rules)
+	require.Equal(t, int64(1080), quote.Subtotal)
+
+	require.Len(t, quote.Discounts, 1)
+	require.Equal(t, int64(108), quote.Discounts[0].Amount)
+	require.Equal(t, int64(972), quote.Total)
+}
+
+func TestValidatePricingRule(t *testing.T) {
+	v := validator.New()
+	ValidatePricingRule(v, &PricingRule{Kind: PricingSeason})
+	require.Contains(t, v.Errors, "startDate")
+	require.Contains(t, v.Errors, "endDate")
+	require.Contains(t, v.Errors, "price")
+
+	v = validator.New()
+	ValidatePricingRule(v, &PricingRule{Kind: PricingLengthOfStay, MinNights: 1, Percent: 100})
+	require.Contains(t, v.Errors, "minNights")
+	require.Contains(t, v.Errors, "percent")
+
+	v = validator.New()
+	ValidatePricingRule(v, &PricingRule{Kind: "holiday"})
+	require.Contains(t, v.Errors, "kind")
+}
+
+func TestPricingRuleModel_InsertAndGetForListing(t *testing.T) {
+	user := CreateRandomUser(t)
+	listing := CreateRandomListing(t, user)
+	start := truncateDate(time.Now().AddDate(0, 1, 0))
+	end := start.AddDate(0, 0, 14)
+
+	season := &PricingRule{ListingID: listing.ID, Kind: PricingSeason, StartDate: &start, EndDate: &end, Price: 250}
+	err := testQueries.PricingRules.Insert(season)
+	require.NoError(t, err)
+	require.NotZero(t, season.ID)
+
+	weekend := &PricingRule{ListingID: listing.ID, Kind: PricingWeekend, Percent: 20}
+	err = testQueries.PricingRules.Insert(weekend)
+	require.NoError(t, err)
+
+	rules, err := testQueries.PricingRules.GetForListing(listing.ID)
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+	require.Equal(t, start.Format(DateLayout), rules[0].StartDate.Format(DateLayout))
+	require.Equal(t, defaultWeekendDays, rules[1].Weekdays)
+
+	quote, err := testQueries.Quotes.Get(QuoteRequest{ListingID: listing.ID, CheckIn: start, CheckOut: start.AddDate(0, 0, 1), Guests: 1})
+	require.NoError(t, err)
+	expected := int64(250)
+	if start.Weekday() == time.Friday || start.Weekday() == time.Saturday {
+		expected = 300
+	}
+	require.Equal(t, expected, quote.Nights[0].Price)
+}
+
+func TestPricingRuleModel_Delete(t *testing.T) {
+	user := CreateRandomUser(t)
+	listing := CreateRandomListing(t, user)
+
+	rule := &PricingRule{ListingID: listing.ID, Kind: PricingLengthOfStay, MinNights: 7, Percent: 10}
+	err := testQueries.PricingRules.Insert(rule)
+	require.NoError(t, err)
+
+	err = testQueries.PricingRules.Delete(rule.ID, listing.ID)
+	require.NoError(t, err)
+
+	err = testQueries.PricingRules.Delete(rule.ID, listing.ID)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
@@ -0,0 +1,265 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/air-bnb/internal/validator"
+	"time"
+)
+
+var ErrGuestCapacity = errors.New("guests exceed listing capacity")
+
+type QuoteModel struct {
+	DB *sql.DB
+}
+
+type QuoteRequest struct {
+	ListingID int64
+	CheckIn   time.Time
+	CheckOut  time.Time
+	Guests    int64
+}
+
+type QuoteNight struct {
+	Date  string   `json:"date"`
+	Price int64    `json:"price"`
+	Rules []string `json:"rules,omitempty"`
+}
+
+type QuoteFee struct {
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	Amount int64  `json:"amount"`
+}
+
+type Quote struct {
+	ListingID      int64        `json:"listingId"`
+	CheckIn        string       `json:"checkIn"`
+	CheckOut       string       `json:"checkOut"`
+	Guests         int64        `json:"guests"`
+	BasePrice      int64        `json:"basePrice"`
+	Nights         []QuoteNight `json:"nights"`
+	NumberOfNights int64        `json:"numberOfNights"`
+	Subtotal       int64        `json:"subtotal"`
+	Discounts      []QuoteFee   `json:"discounts"`
+	Fees           []QuoteFee   `json:"fees"`
+	Taxes          []QuoteFee   `json:"taxes"`
+	Total          int64        `json:"total"`
+}
+
+// quotePricing is everything about a listing that affects what a stay costs.
+type quotePricing struct {
+	price             int64
+	cleaningFee       int64
+	serviceFeePercent int64
+	baseOccupancy     int64
+	extraGuestFee     int64
+	rules             []*PricingRule
+	taxes             []*TaxRule
+}
+
+func ValidateQuoteRequest(v *validator.Validator, req QuoteRequest) {
+	v.Check(!req.CheckIn.IsZero(), "checkIn", "must be provided")
+	v.Check(!req.CheckOut.IsZero(), "checkOut", "must be provided")
+	v.Check(req.CheckOut.After(req.CheckIn), "checkOut", "must be after check-in")
+	v.Check(nightCount(req.CheckIn, req.CheckOut) <= 365, "checkOut", "stay must not be longer than 365 nights")
+	v.Check(req.Guests > 0, "guests", "must be greater than zero")
+}
+
+// Get prices a stay from the listing's nightly rate, pricing rules, fees and the occupancy taxes of
+// its region. Client-supplied prices are never used.
+func (m QuoteModel) Get(req QuoteRequest) (*Quote, error) {
+	query := `SELECT price, guests, cleaning_fee, service_fee_percent, base_occupancy, extra_guest_fee,
+			  location_flag, location_region
+			  FROM listings WHERE id = $1`
+
+	var pricing quotePricing
+	var capacity int64
+	var flag, region string
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, req.ListingID).Scan(
+		&pricing.price,
+		&capacity,
+		&pricing.cleaningFee,
+		&pricing.serviceFeePercent,
+		&pricing.baseOccupancy,
+		&pricing.extraGuestFee,
+		&flag,
+		&region,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	if req.Guests > capacity {
+		return nil, ErrGuestCapacity
+	}
+
+	pricing.rules, err = PricingRuleModel{DB: m.DB}.GetForListing(req.ListingID)
+	if err != nil {
+		return nil, err
+	}
+
+	pricing.taxes, err = TaxRuleModel{DB: m.DB}.GetForLocation(flag, region)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildQuote(req, pricing), nil
+}
+
+// buildQuote prices each night on its own so that stays spanning seasons or weekends are charged
+// correctly. Weekday and season rules replace the base price, in that order of precedence; weekend
+// surcharges are added on top, and the best length-of-stay discount comes off the subtotal. Guests
+// above the base occupancy pay the extra-guest fee for every night. The cleaning fee, service fee and
+// taxes are then charged on the discounted amount plus those fees.
+func buildQuote(req QuoteRequest, pricing quotePricing) *Quote {
+	quote := &Quote{
+		ListingID: req.ListingID,
+		CheckIn:   req.CheckIn.Format(DateLayout),
+		CheckOut:  req.CheckOut.Format(DateLayout),
+		Guests:    req.Guests,
+		BasePrice: pricing.price,
+		Nights:    []QuoteNight{},
+		Discounts: []QuoteFee{},
+		Fees:      []QuoteFee{},
+		Taxes:     []QuoteFee{},
+	}
+
+	for _, night := range nightsBetween(req.CheckIn, req.CheckOut) {
+		quote.Nights = append(quote.Nights, priceNight(night, pricing.price, pricing.rules))
+	}
+	quote.NumberOfNights = int64(len(quote.Nights))
+
+	for _, night := range quote.Nights {
+		quote.Subtotal += night.Price
+	}
+
+	var discount *PricingRule
+	for _, rule := range pricing.rules {
+		if rule.Kind == PricingLengthOfStay && quote.NumberOfNights >= rule.MinNights {
+			if discount == nil || rule.MinNights > discount.MinNights {
+				discount = rule
+			}
+		}
+	}
+	if discount != nil {
+		quote.Discounts = append(quote.Discounts, QuoteFee{
+			Kind:   LineItemDiscount,
+			Name:   fmt.Sprintf("%d%% discount for stays of %d+ nights", discount.Percent, discount.MinNights),
+			Amount: quote.Subtotal * discount.Percent / 100,
+		})
+	}
+
+	accommodation := quote.Subtotal
+	for _, discount := range quote.Discounts {
+		accommodation -= discount.Amount
+	}
+
+	var extraGuests int64
+	if pricing.baseOccupancy > 0 && req.Guests > pricing.baseOccupancy {
+		extraGuests = req.Guests - pricing.baseOccupancy
+	}
+	extraGuestFee := pricing.extraGuestFee * extraGuests * quote.NumberOfNights
+	if extraGuestFee > 0 {
+		quote.Fees = append(quote.Fees, QuoteFee{
+			Kind:   LineItemExtraGuestFee,
+			Name:   fmt.Sprintf("Extra guest fee for %d guests", extraGuests),
+			Amount: extraGuestFee,
+		})
+	}
+
+	if pricing.cleaningFee > 0 {
+		quote.Fees = append(quote.Fees, QuoteFee{Kind: LineItemCleaningFee, Name: "Cleaning fee", Amount: pricing.cleaningFee})
+	}
+	taxable := accommodation + extraGuestFee + pricing.cleaningFee
+
+	if pricing.serviceFeePercent > 0 {
+		quote.Fees = append(quote.Fees, QuoteFee{
+			Kind:   LineItemServiceFee,
+			Name:   "Service fee",
+			Amount: taxable * pricing.serviceFeePercent / 100,
+		})
+	}
+
+	for _, tax := range pricing.taxes {
+		amount := taxable*tax.RateBasisPoints/10_000 + tax.PerGuestPerNight*req.Guests*quote.NumberOfNights
+		if amount > 0 {
+			quote.Taxes = append(quote.Taxes, QuoteFee{Kind: LineItemTax, Name: tax.Name, Amount: amount})
+		}
+	}
+
+	quote.Total = accommodation
+	for _, fee := range quote.Fees {
+		quote.Total += fee.Amount
+	}
+	for _, tax := range quote.Taxes {
+		quote.Total += tax.Amount
+	}
+
+	return quote
+}
+
+// LineItems itemises the quote for storage with a booking. Discounts are negative so that the
+// amounts always add up to the quote's total.
+func (q *Quote) LineItems() []*LineItem {
+	var items []*LineItem
+
+	for _, night := range q.Nights {
+		items = append(items, &LineItem{
+			Kind:        LineItemNight,
+			Description: "Night of " + night.Date,
+			Quantity:    1,
+			UnitAmount:  night.Price,
+			Amount:      night.Price,
+		})
+	}
+	for _, discount := range q.Discounts {
+		items = append(items, &LineItem{Kind: discount.Kind, Description: discount.Name, Quantity: 1, UnitAmount: -discount.Amount, Amount: -discount.Amount})
+	}
+	for _, fee := range append(q.Fees, q.Taxes...) {
+		items = append(items, &LineItem{Kind: fee.Kind, Description: fee.Name, Quantity: 1, UnitAmount: fee.Amount, Amount: fee.Amount})
+	}
+
+	for i, item := range items {
+		item.Position = int64(i + 1)
+	}
+
+	return items
+}
+
+func priceNight(night time.Time, base int64, rules []*PricingRule) QuoteNight {
+	quoted := QuoteNight{Date: night.Format(DateLayout), Price: base}
+
+	replacedBy := ""
+	for _, kind := range []string{PricingWeekday, PricingSeason} {
+		for _, rule := range rules {
+			if rule.Kind == kind && rule.covers(night) {
+				quoted.Price = rule.Price
+				replacedBy = kind
+			}
+		}
+	}
+	if replacedBy != "" {
+		quoted.Rules = append(quoted.Rules, replacedBy)
+	}
+
+	for _, rule := range rules {
+		if rule.Kind == PricingWeekend && rule.covers(night) {
+			quoted.Price += quoted.Price * rule.Percent / 100
+			quoted.Rules = append(quoted.Rules, PricingWeekend)
+		}
+	}
+
+	return quoted
+}
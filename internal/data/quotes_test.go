@@ -0,0 +1,183 @@
+package data
+
+import (
+	"github.com/air-bnb/internal/validator"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestBuildQuote(t *testing.T) {
+	checkIn := time.Date(2024, time.March, 30, 15, 0, 0, 0, time.UTC)
+	req := QuoteRequest{
+		ListingID: 1,
+		CheckIn:   checkIn,
+		CheckOut:  checkIn.AddDate(0, 0, 3),
+		Guests:    2,
+	}
+
+	quote := buildQuote(req, quotePricing{price: 120})
+	require.Equal(t, int64(3), quote.NumberOfNights)
+	require.Len(t, quote.Nights, 3)
+	require.Equal(t, "2024-03-30", quote.Nights[0].Date)
+	require.Equal(t, "2024-04-01", quote.Nights[2].Date)
+	require.Equal(t, int64(360), quote.Subtotal)
+	require.Equal(t, int64(360), quote.Total)
+	require.NotNil(t, quote.Fees)
+}
+
+func TestBuildQuote_FeesAndTaxes(t *testing.T) {
+	checkIn := time.Date(2024, time.March, 4, 0, 0, 0, 0, time.UTC)
+	req := QuoteRequest{ListingID: 1, CheckIn: checkIn, CheckOut: checkIn.AddDate(0, 0, 7), Guests: 2}
+
+	quote := buildQuote(req, quotePricing{
+		price:             100,
+		cleaningFee:       50,
+		serviceFeePercent: 10,
+		rules:             []*PricingRule{{Kind: PricingLengthOfStay, MinNights: 7, Percent: 10}},
+		taxes: []*TaxRule{
+			{Name: "VAT", RateBasisPoints: 1300},
+			{Name: "Tourist tax", PerGuestPerNight: 2},
+		},
+	})
+
+	// 700 subtotal, 70 discount, 50 cleaning: 680 is charged service fee and VAT.
+	require.Equal(t, int64(700), quote.Subtotal)
+	require.Equal(t, []QuoteFee{
+		{Kind: LineItemCleaningFee, Name: "Cleaning fee", Amount: 50},
+		{Kind: LineItemServiceFee, Name: "Service fee", Amount: 68},
+	}, quote.Fees)
+	require.Equal(t, []QuoteFee{
+		{Kind: LineItemTax, Name: "VAT", Amount: 88},
+		{Kind: LineItemTax, Name: "Tourist tax", Amount: 28},
+	}, quote.Taxes)
+	require.Equal(t, int64(630+50+68+88+28), quote.Total)
+
+	items := quote.LineItems()
+	require.Len(t, items, 7+1+2+2)
+
+	var sum int64
+	for i, item := range items {
+		require.Equal(t, int64(i+1), item.Position)
+		sum += item.Amount
+	}
+	require.Equal(t, quote.Total, sum)
+	require.Equal(t, LineItemDiscount, items[7].Kind)
+	require.Equal(t, int64(-70), items[7].Amount)
+}
+
+func TestBuildQuote_ExtraGuestFee(t *testing.T) {
+	checkIn := time.Date(2024, time.March, 4, 0, 0, 0, 0, time.UTC)
+	req := QuoteRequest{ListingID: 1, CheckIn: checkIn, CheckOut: checkIn.AddDate(0, 0, 3), Guests: 4}
+
+	quote := buildQuote(req, quotePricing{price: 100, serviceFeePercent: 10, baseOccupancy: 2, extraGuestFee: 15})
+
+	// Two guests above the base occupancy for three nights; the service fee is charged on it too.
+	require.Equal(t, []QuoteFee{
+		{Kind: LineItemExtraGuestFee, Name: "Extra guest fee for 2 guests", Amount: 90},
+		{Kind: LineItemServiceFee, Name: "Service fee", Amount: 39},
+	}, quote.Fees)
+	require.Equal(t, int64(300+90+39), quote.Total)
+
+	req.Guests = 2
+	quote = buildQuote(req, quotePricing{price: 100, baseOccupancy: 2, extraGuestFee: 15})
+	require.Empty(t, quote.Fees)
+	require.Equal(t, int64(300), quote.Total)
+}
+
+func TestValidateQuoteRequest(t *testing.T) {
+	checkIn := time.Now().AddDate(0, 0, 10)
+	req := QuoteRequest{
+		ListingID: 1,
+		CheckIn:   checkIn,
+		CheckOut:  checkIn,
+		Guests:    0,
+	}
+
+	v := validator.New()
+	ValidateQuoteRequest(v, req)
+	require.Contains(t, v.Errors, "checkOut")
+	require.Contains(t, v.Errors, "guests")
+
+	req.CheckIn = time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC)
+	req.CheckOut = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+	req.Guests = 1
+	v = validator.New()
+	ValidateQuoteRequest(v, req)
+	require.Equal(t, "stay must not be longer than 365 nights", v.Errors["checkOut"])
+}
+
+func TestNightCount(t *testing.T) {
+	checkIn := time.Date(2030, 3, 30, 15, 0, 0, 0, time.UTC)
+	for _, checkOut := range []time.Time{checkIn, checkIn.AddDate(0, 0, 3), checkIn.AddDate(0, 0, -2), checkIn.AddDate(1, 0, 0)} {
+		require.EqualValues(t, len(nightsBetween(checkIn, checkOut)), nightCount(checkIn, checkOut))
+	}
+}
+
+func TestQuoteModel_Get(t *testing.T) {
+	user := CreateRandomUser(t)
+	listing := CreateRandomListing(t, user)
+	checkIn := time.Now().AddDate(0, 0, 10)
+
+	quote, err := testQueries.Quotes.Get(QuoteRequest{
+		ListingID: listing.ID,
+		CheckIn:   checkIn,
+		CheckOut:  checkIn.AddDate(0, 0, 4),
+		Guests:    1,
+	})
+	require.NoError(t, err)
+	require.Equal(t, listing.Price, quote.BasePrice)
+	require.Equal(t, int64(4), quote.NumberOfNights)
+	require.Equal(t, listing.Price*4, quote.Total)
+}
+
+func TestQuoteModel_Get_GuestCapacity(t *testing.T) {
+	user := CreateRandomUser(t)
+	listing := CreateRandomListing(t, user)
+	checkIn := time.Now().AddDate(0, 0, 10)
+
+	_, err := testQueries.Quotes.Get(QuoteRequest{
+		ListingID: listing.ID,
+		CheckIn:   checkIn,
+		CheckOut:  checkIn.AddDate(0, 0, 1),
+		Guests:    listing.Guests + 1,
+	})
+	require.ErrorIs(t, err, ErrGuestCapacity)
+}
+
+func TestQuoteModel_Get_NotFound(t *testing.T) {
+	checkIn := time.Now().AddDate(0, 0, 10)
+
+	_, err := testQueries.Quotes.Get(QuoteRequest{
+		ListingID: -1,
+		CheckIn:   checkIn,
+		CheckOut:  checkIn.AddDate(0, 0, 1),
+		Guests:    1,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestQuoteModel_Get_Taxes(t *testing.T) {
+	user := CreateRandomUser(t)
+	listing := CreateRandomListing(t, user)
+	checkIn := time.Now().AddDate(0, 0, 10)
+
+	err := testQueries.TaxRules.Insert(&TaxRule{
+		LocationFlag:     listing.Location.Flag,
+		LocationRegion:   listing.Location.Region,
+		Name:             "City tax",
+		PerGuestPerNight: 3,
+	})
+	require.NoError(t, err)
+
+	quote, err := testQueries.Quotes.Get(QuoteRequest{
+		ListingID: listing.ID,
+		CheckIn:   checkIn,
+		CheckOut:  checkIn.AddDate(0, 0, 2),
+		Guests:    1,
+	})
+	require.NoError(t, err)
+	require.Len(t, quote.Taxes, 1)
+	require.Equal(t, int64(6), quote.Taxes[0].Amount)
+	require.Equal(t, listing.Price*2+6, quote.Total)
+}
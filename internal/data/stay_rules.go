@@ -0,0 +1,129 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/air-bnb/internal/validator"
+	"time"
+)
+
+type StayRuleModel struct {
+	DB *sql.DB
+}
+
+// StayRules limit which stays can be booked on a listing. Weekdays follow time.Weekday, so 0 is Sunday.
+type StayRules struct {
+	ListingID         int64   `json:"listingId"`
+	MinNights         int64   `json:"minNights"`
+	MaxNights         int64   `json:"maxNights"`
+	CheckInWeekdays   []int64 `json:"checkInWeekdays"`
+	AdvanceNoticeDays int64   `json:"advanceNoticeDays"`
+}
+
+// DefaultStayRules are used for listings whose host never configured any rules.
+func DefaultStayRules(listingID int64) *StayRules {
+	return &StayRules{
+		ListingID:         listingID,
+		MinNights:         1,
+		MaxNights:         365,
+		CheckInWeekdays:   []int64{0, 1, 2, 3, 4, 5, 6},
+		AdvanceNoticeDays: 0,
+	}
+}
+
+func ValidateStayRules(v *validator.Validator, rules *StayRules) {
+	v.Check(rules.MinNights > 0, "minNights", "must be greater than zero")
+	v.Check(rules.MaxNights <= 365, "maxNights", "must not be more than 365")
+	v.Check(rules.MaxNights >= rules.MinNights, "maxNights", "must not be less than minNights")
+
+	v.Check(len(rules.CheckInWeekdays) > 0, "checkInWeekdays", "must contain at least one weekday")
+	v.Check(validator.Unique(rules.CheckInWeekdays), "checkInWeekdays", "must not contain duplicate values")
+	for _, day := range rules.CheckInWeekdays {
+		v.Check(day >= 0 && day <= 6, "checkInWeekdays", "must only contain values between 0 (Sunday) and 6 (Saturday)")
+	}
+
+	v.Check(rules.AdvanceNoticeDays >= 0, "advanceNoticeDays", "must not be negative")
+	v.Check(rules.AdvanceNoticeDays <= 365, "advanceNoticeDays", "must not be more than 365")
+}
+
+// ValidateStay checks a requested stay against the listing's rules and blocked dates.
+func ValidateStay(v *validator.Validator, rules *StayRules, blocks []*BlockedDate, checkIn, checkOut, now time.Time) {
+	nights := nightCount(checkIn, checkOut)
+	v.Check(nights >= rules.MinNights, "checkOut", fmt.Sprintf("stay must be at least %d nights", rules.MinNights))
+	v.Check(nights <= rules.MaxNights, "checkOut", fmt.Sprintf("stay must not be more than %d nights", rules.MaxNights))
+
+	weekday := int64(checkIn.Weekday())
+	v.Check(validator.PermittedValue(weekday, rules.CheckInWeekdays...), "checkIn",
+		fmt.Sprintf("check-in is not allowed on %s", checkIn.Weekday()))
+
+	earliest := truncateDate(now).AddDate(0, 0, int(rules.AdvanceNoticeDays))
+	v.Check(!truncateDate(checkIn).Before(earliest), "checkIn",
+		fmt.Sprintf("must be at least %d days from today", rules.AdvanceNoticeDays))
+
+	v.Check(len(blocks) == 0, "checkIn", "stay includes dates blocked by the host")
+}
+
+func ValidateBlockedDate(v *validator.Validator, block *BlockedDate) {
+	v.Check(!block.StartDate.IsZero(), "startDate", "must be provided")
+	v.Check(!block.EndDate.IsZero(), "endDate", "must be provided")
+	v.Check(block.EndDate.After(block.StartDate), "endDate", "must be after startDate")
+	v.Check(nightCount(block.StartDate, block.EndDate) <= 366, "endDate", "must not be more than 366 days after startDate")
+	v.Check(len(block.Reason) <= 500, "reason", "must not be more than 500 characters long")
+}
+
+// Get returns the listing's rules, falling back to DefaultStayRules when none were saved.
+func (m StayRuleModel) Get(listingID int64) (*StayRules, error) {
+	query := `SELECT listing_id, min_nights, max_nights, check_in_weekdays, advance_notice_days
+			  FROM listing_rules
+			  WHERE listing_id = $1`
+
+	var rules StayRules
+	var weekdays string
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, listingID).Scan(
+		&rules.ListingID,
+		&rules.MinNights,
+		&rules.MaxNights,
+		&weekdays,
+		&rules.AdvanceNoticeDays,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return DefaultStayRules(listingID), nil
+		default:
+			return nil, err
+		}
+	}
+	rules.CheckInWeekdays = LoadPgIntArray(weekdays)
+
+	return &rules, nil
+}
+
+func (m StayRuleModel) Upsert(rules *StayRules) error {
+	query := `INSERT INTO listing_rules (listing_id, min_nights, max_nights, check_in_weekdays, advance_notice_days)
+			  VALUES ($1, $2, $3, $4, $5)
+			  ON CONFLICT (listing_id) DO UPDATE
+			  SET min_nights = EXCLUDED.min_nights, max_nights = EXCLUDED.max_nights,
+			  check_in_weekdays = EXCLUDED.check_in_weekdays,
+			  advance_notice_days = EXCLUDED.advance_notice_days, updated_at = NOW()`
+
+	args := []interface{}{
+		rules.ListingID,
+		rules.MinNights,
+		rules.MaxNights,
+		SavePgIntArray(rules.CheckInWeekdays),
+		rules.AdvanceNoticeDays,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, query, args...)
+	return err
+}
@@ -0,0 +1,84 @@
+package data
+
+import (
+	"github.com/air-bnb/internal/validator"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestValidateStayRules(t *testing.T) {
+	rules := DefaultStayRules(1)
+	v := validator.New()
+	ValidateStayRules(v, rules)
+	require.True(t, v.Valid())
+
+	rules.MinNights = 5
+	rules.MaxNights = 2
+	rules.CheckInWeekdays = []int64{1, 1, 9}
+	v = validator.New()
+	ValidateStayRules(v, rules)
+	require.Contains(t, v.Errors, "maxNights")
+	require.Contains(t, v.Errors, "checkInWeekdays")
+}
+
+func TestValidateStay(t *testing.T) {
+	now := time.Date(2024, time.June, 3, 12, 0, 0, 0, time.UTC) // Monday
+	rules := &StayRules{
+		MinNights:         2,
+		MaxNights:         7,
+		CheckInWeekdays:   []int64{int64(time.Friday), int64(time.Saturday)},
+		AdvanceNoticeDays: 3,
+	}
+
+	friday := time.Date(2024, time.June, 7, 0, 0, 0, 0, time.UTC)
+	v := validator.New()
+	ValidateStay(v, rules, nil, friday, friday.AddDate(0, 0, 2), now)
+	require.True(t, v.Valid())
+
+	v = validator.New()
+	ValidateStay(v, rules, nil, friday, friday.AddDate(0, 0, 1), now)
+	require.Contains(t, v.Errors, "checkOut")
+
+	tuesday := time.Date(2024, time.June, 4, 0, 0, 0, 0, time.UTC)
+	v = validator.New()
+	ValidateStay(v, rules, nil, tuesday, tuesday.AddDate(0, 0, 3), now)
+	require.Contains(t, v.Errors, "checkIn")
+
+	v = validator.New()
+	ValidateStay(v, rules, []*BlockedDate{{StartDate: friday, EndDate: friday.AddDate(0, 0, 1)}}, friday, friday.AddDate(0, 0, 2), now)
+	require.Contains(t, v.Errors, "checkIn")
+}
+
+func TestLoadPgIntArray(t *testing.T) {
+	require.Equal(t, []int64{0, 5, 6}, LoadPgIntArray(SavePgIntArray([]int64{0, 5, 6})))
+	require.Equal(t, []int64{}, LoadPgIntArray("{}"))
+}
+
+func TestStayRuleModel_Get_Default(t *testing.T) {
+	user := CreateRandomUser(t)
+	listing := CreateRandomListing(t, user)
+
+	rules, err := testQueries.StayRules.Get(listing.ID)
+	require.NoError(t, err)
+	require.Equal(t, DefaultStayRules(listing.ID), rules)
+}
+
+func TestStayRuleModel_Upsert(t *testing.T) {
+	user := CreateRandomUser(t)
+	listing := CreateRandomListing(t, user)
+
+	rules := DefaultStayRules(listing.ID)
+	rules.MinNights = 3
+	rules.CheckInWeekdays = []int64{5, 6}
+	err := testQueries.StayRules.Upsert(rules)
+	require.NoError(t, err)
+
+	rules.AdvanceNoticeDays = 2
+	err = testQueries.StayRules.Upsert(rules)
+	require.NoError(t, err)
+
+	fromDB, err := testQueries.StayRules.Get(listing.ID)
+	require.NoError(t, err)
+	require.Equal(t, rules, fromDB)
+}
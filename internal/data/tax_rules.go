@@ -0,0 +1,124 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"github.com/air-bnb/internal/validator"
+	"time"
+)
+
+type TaxRuleModel struct {
+	DB *sql.DB
+}
+
+// TaxRule is an occupancy tax for a country (location_flag) or one of its regions. RateBasisPoints is
+// charged on the accommodation and cleaning amount (250 is 2.5%); PerGuestPerNight is a flat charge.
+type TaxRule struct {
+	ID               int64  `json:"id"`
+	LocationFlag     string `json:"locationFlag"`
+	LocationRegion   string `json:"locationRegion"`
+	Name             string `json:"name"`
+	RateBasisPoints  int64  `json:"rateBasisPoints"`
+	PerGuestPerNight int64  `json:"perGuestPerNight"`
+}
+
+func ValidateTaxRule(v *validator.Validator, rule *TaxRule) {
+	v.Check(rule.LocationFlag != "", "locationFlag", "must be provided")
+	v.Check(len(rule.LocationFlag) <= 255, "locationFlag", "must not be more than 255 characters long")
+	v.Check(len(rule.LocationRegion) <= 255, "locationRegion", "must not be more than 255 characters long")
+
+	v.Check(rule.Name != "", "name", "must be provided")
+	v.Check(len(rule.Name) <= 255, "name", "must not be more than 255 characters long")
+
+	v.Check(rule.RateBasisPoints >= 0 && rule.RateBasisPoints <= 10_000, "rateBasisPoints", "must be between 0 and 10000")
+	v.Check(rule.PerGuestPerNight >= 0, "perGuestPerNight", "must not be negative")
+}
+
+func (m TaxRuleModel) Insert(rule *TaxRule) error {
+	query := `INSERT INTO tax_rules (location_flag, location_region, name, rate_basis_points, per_guest_per_night)
+			  VALUES ($1, $2, $3, $4, $5) RETURNING id`
+
+	args := []interface{}{rule.LocationFlag, rule.LocationRegion, rule.Name, rule.RateBasisPoints, rule.PerGuestPerNight}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.DB.QueryRowContext(ctx, query, args...).Scan(&rule.ID)
+}
+
+// GetAll returns every tax rule, grouped by country and region.
+func (m TaxRuleModel) GetAll() ([]*TaxRule, error) {
+	query := `SELECT id, location_flag, location_region, name, rate_basis_points, per_guest_per_night
+			  FROM tax_rules
+			  ORDER BY location_flag, location_region, id`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTaxRules(rows)
+}
+
+// GetForLocation returns the country-wide rules for flag plus any rules specific to region.
+func (m TaxRuleModel) GetForLocation(flag, region string) ([]*TaxRule, error) {
+	query := `SELECT id, location_flag, location_region, name, rate_basis_points, per_guest_per_night
+			  FROM tax_rules
+			  WHERE location_flag = $1 AND (location_region = '' OR location_region = $2)
+			  ORDER BY id`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, flag, region)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTaxRules(rows)
+}
+
+func scanTaxRules(rows *sql.Rows) ([]*TaxRule, error) {
+	rules := []*TaxRule{}
+	for rows.Next() {
+		var rule TaxRule
+		err := rows.Scan(&rule.ID, &rule.LocationFlag, &rule.LocationRegion, &rule.Name, &rule.RateBasisPoints, &rule.PerGuestPerNight)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, &rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+func (m TaxRuleModel) Delete(id int64) error {
+	query := `DELETE FROM tax_rules WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
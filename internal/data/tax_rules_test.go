@@ -0,0 +1,37 @@
+package data
+
+import (
+	"github.com/air-bnb/internal/random"
+	"github.com/air-bnb/internal/validator"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestValidateTaxRule(t *testing.T) {
+	v := validator.New()
+	ValidateTaxRule(v, &TaxRule{LocationFlag: "HR", Name: "VAT", RateBasisPoints: 1300})
+	require.True(t, v.Valid(), v.Errors)
+
+	v = validator.New()
+	ValidateTaxRule(v, &TaxRule{RateBasisPoints: 10_001, PerGuestPerNight: -1})
+	require.Contains(t, v.Errors, "locationFlag")
+	require.Contains(t, v.Errors, "name")
+	require.Contains(t, v.Errors, "rateBasisPoints")
+	require.Contains(t, v.Errors, "perGuestPerNight")
+}
+
+func TestTaxRuleModel_Delete(t *testing.T) {
+	rule := &TaxRule{LocationFlag: random.RandString(3), Name: "City tax", PerGuestPerNight: 2}
+	require.NoError(t, testQueries.TaxRules.Insert(rule))
+
+	rules, err := testQueries.TaxRules.GetAll()
+	require.NoError(t, err)
+	require.Contains(t, rules, rule)
+
+	require.NoError(t, testQueries.TaxRules.Delete(rule.ID))
+	require.ErrorIs(t, testQueries.TaxRules.Delete(rule.ID), ErrRecordNotFound)
+
+	rules, err = testQueries.TaxRules.GetForLocation(rule.LocationFlag, "")
+	require.NoError(t, err)
+	require.Empty(t, rules)
+}
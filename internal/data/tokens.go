@@ -37,18 +37,29 @@ func generateToken(userId int64, expiry time.Duration, scope string) (*Token, er
 		Scope:  scope,
 	}
 
-	randomBytes := make([]byte, 16)
-	_, err := rand.Read(randomBytes)
+	var err error
+	token.Plaintext, token.Hash, err = generateSecret()
 	if err != nil {
 		return nil, err
 	}
-	token.Plaintext = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(randomBytes)
-	hash := sha256.Sum256([]byte(token.Plaintext))
-	token.Hash = hash[:]
 
 	return token, nil
 }
 
+// generateSecret returns a random 26 character secret and the SHA-256 hash that is stored in its place.
+func generateSecret() (string, []byte, error) {
+	randomBytes := make([]byte, 16)
+	_, err := rand.Read(randomBytes)
+	if err != nil {
+		return "", nil, err
+	}
+
+	plaintext := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(randomBytes)
+	hash := sha256.Sum256([]byte(plaintext))
+
+	return plaintext, hash[:], nil
+}
+
 func (m TokenModel) Insert(token *Token) error {
 	query := `
         INSERT INTO tokens (hash, user_id, expiry, scope) 
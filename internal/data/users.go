@@ -31,6 +31,7 @@ type User struct {
 	Image             string    `json:"image,omitempty"`
 	Password          password  `json:"-"`
 	Activated         bool      `json:"activated"`
+	IsAdmin           bool      `json:"isAdmin"`
 	VerificationToken string    `json:"verificationToken,omitempty"`
 	ResetToken        string    `json:"resetToken,omitempty"`
 	ResetEmailToken   string    `json:"resetEmailToken,omitempty"`
@@ -126,7 +127,7 @@ func (m UserModel) Insert(user *User) error {
 
 func (m UserModel) Get(id int64, email string) (*User, error) {
 	query := `SELECT id, created_at, COALESCE(name, ''), email, COALESCE(image, ''),
-       		  COALESCE(password_hash, ''), activated, COALESCE( verification_token, ''),
+       		  COALESCE(password_hash, ''), activated, is_admin, COALESCE( verification_token, ''),
        		  COALESCE(reset_token, ''), COALESCE(update_email_token, '')
 			  FROM users
 			  WHERE id = $1 OR email = $2`
@@ -143,6 +144,7 @@ func (m UserModel) Get(id int64, email string) (*User, error) {
 		&user.Image,
 		&user.Password.hash,
 		&user.Activated,
+		&user.IsAdmin,
 		&user.VerificationToken,
 		&user.ResetToken,
 		&user.ResetEmailToken,
@@ -213,7 +215,7 @@ func (m UserModel) Delete(id int64) error {
 func (m UserModel) GetForToken(tokenScope, tokenPlaintext string) (*User, error) {
 	tokenHash := sha256.Sum256([]byte(tokenPlaintext))
 	query := `
-        SELECT u.id, u.activated, u.created_at, COALESCE(u.name, ''), u.email ,COALESCE(u.image,''), COALESCE(u.password_hash, ''), COALESCE(u.update_email_token, '')
+        SELECT u.id, u.activated, u.is_admin, u.created_at, COALESCE(u.name, ''), u.email ,COALESCE(u.image,''), COALESCE(u.password_hash, ''), COALESCE(u.update_email_token, '')
         FROM users u
         INNER JOIN tokens t
         ON u.id = t.user_id
@@ -231,6 +233,7 @@ func (m UserModel) GetForToken(tokenScope, tokenPlaintext string) (*User, error)
 	err := m.DB.QueryRowContext(ctx, query, args...).Scan(
 		&user.ID,
 		&user.Activated,
+		&user.IsAdmin,
 		&user.CreatedAt,
 		&user.Name,
 		&user.Email,
@@ -0,0 +1,36 @@
+// Package ical reads and writes the small part of iCalendar (RFC 5545) that calendar sync between
+// booking platforms uses: all-day VEVENTs in a VCALENDAR.
+package ical
+
+import (
+	"time"
+)
+
+// dateLayout is the RFC 5545 DATE value format.
+const dateLayout = "20060102"
+
+// dateTimeLayout is the RFC 5545 DATE-TIME value format in UTC.
+const dateTimeLayout = "20060102T150405Z"
+
+const (
+	StatusTentative = "TENTATIVE"
+	StatusConfirmed = "CONFIRMED"
+	StatusCancelled = "CANCELLED"
+)
+
+type Calendar struct {
+	ProdID string
+	Name   string
+	Events []Event
+}
+
+// Event is an all-day event. End is exclusive, the same as DTEND for DATE values.
+type Event struct {
+	UID         string
+	Stamp       time.Time
+	Start       time.Time
+	End         time.Time
+	Summary     string
+	Description string
+	Status      string
+}
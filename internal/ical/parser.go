@@ -0,0 +1,170 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCalendar = errors.New("invalid iCalendar data")
+
+// maxLineBytes caps a single unfolded content line so that a hostile feed can't exhaust memory.
+const maxLineBytes = 64 * 1024
+
+// Parse reads the VEVENTs of a VCALENDAR. Only the properties Event has are kept. DATE-TIME starts
+// and ends are reduced to their date, since stays are booked by the night; an event without an
+// end lasts one day, as RFC 5545 specifies for DATE starts.
+func Parse(r io.Reader) (*Calendar, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	cal := &Calendar{}
+	var event *Event
+	inCalendar := false
+
+	for i, line := range lines {
+		name, value, ok := splitLine(line)
+		if !ok {
+			return nil, fmt.Errorf("%w: line %d is not a content line", ErrInvalidCalendar, i+1)
+		}
+
+		switch {
+		case name == "BEGIN" && value == "VCALENDAR":
+			inCalendar = true
+		case name == "END" && value == "VCALENDAR":
+			if !inCalendar {
+				return nil, fmt.Errorf("%w: END:VCALENDAR without BEGIN", ErrInvalidCalendar)
+			}
+			return cal, nil
+		case !inCalendar:
+			return nil, fmt.Errorf("%w: content outside VCALENDAR", ErrInvalidCalendar)
+		case name == "BEGIN" && value == "VEVENT":
+			event = &Event{}
+		case name == "END" && value == "VEVENT":
+			if event == nil {
+				return nil, fmt.Errorf("%w: END:VEVENT without BEGIN", ErrInvalidCalendar)
+			}
+			if event.UID == "" || event.Start.IsZero() {
+				return nil, fmt.Errorf("%w: event on line %d has no UID or DTSTART", ErrInvalidCalendar, i+1)
+			}
+			if event.End.IsZero() {
+				event.End = event.Start.AddDate(0, 0, 1)
+			}
+			cal.Events = append(cal.Events, *event)
+			event = nil
+		case event == nil:
+			if name == "PRODID" {
+				cal.ProdID = value
+			}
+			if name == "X-WR-CALNAME" {
+				cal.Name = unescapeText(value)
+			}
+		default:
+			err := event.set(name, value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: line %d: %s", ErrInvalidCalendar, i+1, err)
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("%w: missing END:VCALENDAR", ErrInvalidCalendar)
+}
+
+func (e *Event) set(name, value string) error {
+	var err error
+
+	switch name {
+	case "UID":
+		e.UID = value
+	case "DTSTAMP":
+		e.Stamp, err = parseDate(value)
+	case "DTSTART":
+		e.Start, err = parseDate(value)
+	case "DTEND":
+		e.End, err = parseDate(value)
+	case "SUMMARY":
+		e.Summary = unescapeText(value)
+	case "DESCRIPTION":
+		e.Description = unescapeText(value)
+	case "STATUS":
+		e.Status = strings.ToUpper(value)
+	}
+
+	return err
+}
+
+// parseDate accepts DATE and DATE-TIME values and returns midnight UTC of the date.
+func parseDate(value string) (time.Time, error) {
+	if len(value) < len(dateLayout) {
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+
+	date, err := time.Parse(dateLayout, value[:len(dateLayout)])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+
+	return date, nil
+}
+
+// unfold joins folded lines and drops blank ones. Both CRLF and bare LF line endings are accepted,
+// since plenty of feeds in the wild use the latter.
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), maxLineBytes)
+
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+
+		if (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			last := len(lines) - 1
+			if len(lines[last])+len(line) > maxLineBytes {
+				return nil, fmt.Errorf("%w: line too long", ErrInvalidCalendar)
+			}
+			lines[last] += line[1:]
+			continue
+		}
+
+		lines = append(lines, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, fmt.Errorf("%w: line too long", ErrInvalidCalendar)
+		}
+		return nil, err
+	}
+
+	return lines, nil
+}
+
+// splitLine splits "NAME;PARAM=x:value" into its name and value; parameters are ignored. Parameter
+// values may be quoted and contain colons, so the value starts at the first colon outside quotes.
+func splitLine(line string) (name, value string, ok bool) {
+	quoted := false
+	for i, c := range line {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case c == ':' && !quoted:
+			name, _, _ = strings.Cut(line[:i], ";")
+			return strings.ToUpper(name), line[i+1:], name != ""
+		}
+	}
+	return "", "", false
+}
+
+var textUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+
+func unescapeText(s string) string {
+	return textUnescaper.Replace(s)
+}
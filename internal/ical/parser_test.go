@@ -0,0 +1,87 @@
+package ical
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	feed := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Example//EN",
+		"BEGIN:VEVENT",
+		"UID:abc@example.com",
+		"DTSTART;VALUE=DATE:20240601",
+		"DTEND;VALUE=DATE:20240604",
+		"SUMMARY:Reserved\\, paid",
+		"DESCRIPTION:first line\\nsecond ",
+		" line",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:def@example.com",
+		`DTSTART;TZID="Europe/Zagreb:x":20240710T150000`,
+		"STATUS:cancelled",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\n")
+
+	cal, err := Parse(strings.NewReader(feed))
+	require.NoError(t, err)
+	require.Equal(t, "-//Example//EN", cal.ProdID)
+	require.Len(t, cal.Events, 2)
+
+	first := cal.Events[0]
+	require.Equal(t, "abc@example.com", first.UID)
+	require.Equal(t, time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC), first.Start)
+	require.Equal(t, time.Date(2024, time.June, 4, 0, 0, 0, 0, time.UTC), first.End)
+	require.Equal(t, "Reserved, paid", first.Summary)
+	require.Equal(t, "first line\nsecond line", first.Description)
+
+	second := cal.Events[1]
+	require.Equal(t, time.Date(2024, time.July, 10, 0, 0, 0, 0, time.UTC), second.Start)
+	require.Equal(t, time.Date(2024, time.July, 11, 0, 0, 0, 0, time.UTC), second.End)
+	require.Equal(t, StatusCancelled, second.Status)
+}
+
+func TestParse_RoundTrip(t *testing.T) {
+	want := &Calendar{
+		ProdID: "-//air-bnb//calendar//EN",
+		Name:   strings.Repeat("Lake house; sleeps 6, ", 5),
+		Events: []Event{{
+			UID:         "booking-1@air-bnb",
+			Stamp:       time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC),
+			Start:       time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC),
+			End:         time.Date(2024, time.June, 4, 0, 0, 0, 0, time.UTC),
+			Summary:     "Reserved",
+			Description: strings.Repeat("a long description, ", 10),
+			Status:      StatusConfirmed,
+		}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, want.Write(&buf))
+
+	got, err := Parse(&buf)
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"not a calendar":  "<html></html>",
+		"unterminated":    "BEGIN:VCALENDAR\nBEGIN:VEVENT\nUID:a\nDTSTART:20240101\nEND:VEVENT",
+		"missing dtstart": "BEGIN:VCALENDAR\nBEGIN:VEVENT\nUID:a\nEND:VEVENT\nEND:VCALENDAR",
+		"bad date":        "BEGIN:VCALENDAR\nBEGIN:VEVENT\nUID:a\nDTSTART:2024-01-01\nEND:VEVENT\nEND:VCALENDAR",
+	}
+
+	for name, feed := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(feed))
+			require.ErrorIs(t, err, ErrInvalidCalendar)
+		})
+	}
+}
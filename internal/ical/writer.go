@@ -0,0 +1,78 @@
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxLineOctets is the longest a content line may be before it has to be folded.
+const maxLineOctets = 75
+
+// Write encodes the calendar with CRLF line endings, folding long lines and escaping text values.
+func (c *Calendar) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	line := func(name, value string) {
+		writeFolded(bw, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", c.ProdID)
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	if c.Name != "" {
+		line("X-WR-CALNAME", escapeText(c.Name))
+	}
+
+	for _, event := range c.Events {
+		line("BEGIN", "VEVENT")
+		line("UID", event.UID)
+		line("DTSTAMP", event.Stamp.UTC().Format(dateTimeLayout))
+		line("DTSTART;VALUE=DATE", event.Start.Format(dateLayout))
+		line("DTEND;VALUE=DATE", event.End.Format(dateLayout))
+		if event.Summary != "" {
+			line("SUMMARY", escapeText(event.Summary))
+		}
+		if event.Description != "" {
+			line("DESCRIPTION", escapeText(event.Description))
+		}
+		if event.Status != "" {
+			line("STATUS", event.Status)
+		}
+		// Hosts block these dates elsewhere, so the events must show as busy.
+		line("TRANSP", "OPAQUE")
+		line("END", "VEVENT")
+	}
+
+	line("END", "VCALENDAR")
+
+	return bw.Flush()
+}
+
+// writeFolded writes a content line, breaking it every 75 octets with CRLF and a space. Lines are
+// only broken between characters so that multi-byte UTF-8 sequences stay intact.
+func writeFolded(w *bufio.Writer, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		w.WriteString(line[:cut])
+		w.WriteString("\r\n ")
+		line = line[cut:]
+		// The leading space of a continuation line counts towards its length.
+		limit = maxLineOctets - 1
+	}
+	w.WriteString(line)
+	w.WriteString("\r\n")
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
@@ -0,0 +1,60 @@
+package ical
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCalendar_Write(t *testing.T) {
+	stamp := time.Date(2024, time.May, 1, 12, 30, 0, 0, time.UTC)
+	cal := &Calendar{
+		ProdID: "-//air-bnb//calendar//EN",
+		Name:   "Beach house",
+		Events: []Event{{
+			UID:         "booking-1@air-bnb",
+			Stamp:       stamp,
+			Start:       time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC),
+			End:         time.Date(2024, time.June, 4, 0, 0, 0, 0, time.UTC),
+			Summary:     "Reserved",
+			Description: "Painters; back door, side gate\nkey under mat",
+			Status:      StatusConfirmed,
+		}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, cal.Write(&buf))
+
+	out := buf.String()
+	require.True(t, strings.HasPrefix(out, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	require.True(t, strings.HasSuffix(out, "END:VEVENT\r\nEND:VCALENDAR\r\n"))
+	require.Contains(t, out, "\r\nDTSTAMP:20240501T123000Z\r\n")
+	require.Contains(t, out, "\r\nDTSTART;VALUE=DATE:20240601\r\n")
+	require.Contains(t, out, "\r\nDTEND;VALUE=DATE:20240604\r\n")
+	require.Contains(t, out, `DESCRIPTION:Painters\; back door\, side gate\nkey under mat`)
+	require.NotContains(t, strings.ReplaceAll(out, "\r\n", ""), "\n")
+}
+
+func TestWriteFolded(t *testing.T) {
+	var buf bytes.Buffer
+	cal := &Calendar{ProdID: "x", Name: strings.Repeat("é", 100)}
+	require.NoError(t, cal.Write(&buf))
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n")
+	var name strings.Builder
+	for i, line := range lines {
+		require.LessOrEqual(t, len(line), maxLineOctets)
+		if strings.HasPrefix(line, "X-WR-CALNAME:") {
+			name.WriteString(line)
+			for _, next := range lines[i+1:] {
+				if !strings.HasPrefix(next, " ") {
+					break
+				}
+				name.WriteString(next[1:])
+			}
+		}
+	}
+	require.Equal(t, "X-WR-CALNAME:"+strings.Repeat("é", 100), name.String())
+}
@@ -0,0 +1,105 @@
+package payments
+
+import (
+	"context"
+	"sync"
+)
+
+const (
+	FakeProviderName = "fake"
+	// FakeDeclinedMethod is a payment method the fake provider always declines.
+	FakeDeclinedMethod = "tok_declined"
+)
+
+type fakePayment struct {
+	authorized int64
+	captured   int64
+	refunded   int64
+	voided     bool
+}
+
+// Fake is an in-memory provider for development and tests. It never talks to the network and its
+// references are derived from the idempotency key, so the same input always gives the same result.
+type Fake struct {
+	mu       sync.Mutex
+	payments map[string]*fakePayment
+}
+
+func NewFake() *Fake {
+	return &Fake{payments: make(map[string]*fakePayment)}
+}
+
+func (f *Fake) Name() string {
+	return FakeProviderName
+}
+
+func (f *Fake) Authorize(ctx context.Context, req AuthorizeRequest) (string, error) {
+	if req.Amount <= 0 {
+		return "", ErrInvalidAmount
+	}
+	if req.PaymentMethod == FakeDeclinedMethod {
+		return "", ErrDeclined
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	reference := "fake_" + req.IdempotencyKey
+	if _, ok := f.payments[reference]; !ok {
+		f.payments[reference] = &fakePayment{authorized: req.Amount}
+	}
+
+	return reference, nil
+}
+
+func (f *Fake) Capture(ctx context.Context, reference string, amount int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	payment, ok := f.payments[reference]
+	switch {
+	case !ok:
+		return ErrUnknownPayment
+	case payment.voided || payment.captured > 0:
+		return ErrInvalidState
+	case amount <= 0 || amount > payment.authorized:
+		return ErrInvalidAmount
+	}
+
+	payment.captured = amount
+	return nil
+}
+
+func (f *Fake) Refund(ctx context.Context, reference string, amount int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	payment, ok := f.payments[reference]
+	switch {
+	case !ok:
+		return ErrUnknownPayment
+	case payment.captured == 0:
+		return ErrInvalidState
+	case amount <= 0 || payment.refunded+amount > payment.captured:
+		return ErrInvalidAmount
+	}
+
+	payment.refunded += amount
+	return nil
+}
+
+func (f *Fake) Void(ctx context.Context, reference string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	payment, ok := f.payments[reference]
+	switch {
+	case !ok:
+		return ErrUnknownPayment
+	case payment.voided || payment.captured > 0:
+		return ErrInvalidState
+	}
+
+	payment.voided = true
+	return nil
+}
@@ -0,0 +1,59 @@
+package payments
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestFake_Lifecycle(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFake()
+
+	reference, err := fake.Authorize(ctx, AuthorizeRequest{IdempotencyKey: "booking-1", Amount: 500})
+	require.NoError(t, err)
+	require.Equal(t, "fake_booking-1", reference)
+
+	again, err := fake.Authorize(ctx, AuthorizeRequest{IdempotencyKey: "booking-1", Amount: 500})
+	require.NoError(t, err)
+	require.Equal(t, reference, again)
+
+	require.ErrorIs(t, fake.Capture(ctx, reference, 600), ErrInvalidAmount)
+	require.NoError(t, fake.Capture(ctx, reference, 500))
+	require.ErrorIs(t, fake.Capture(ctx, reference, 500), ErrInvalidState)
+	require.ErrorIs(t, fake.Void(ctx, reference), ErrInvalidState)
+
+	require.NoError(t, fake.Refund(ctx, reference, 200))
+	require.NoError(t, fake.Refund(ctx, reference, 300))
+	require.ErrorIs(t, fake.Refund(ctx, reference, 1), ErrInvalidAmount)
+}
+
+func TestFake_Declined(t *testing.T) {
+	_, err := NewFake().Authorize(context.Background(), AuthorizeRequest{
+		IdempotencyKey: "booking-2",
+		Amount:         500,
+		PaymentMethod:  FakeDeclinedMethod,
+	})
+	require.ErrorIs(t, err, ErrDeclined)
+}
+
+func TestFake_Void(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFake()
+
+	reference, err := fake.Authorize(ctx, AuthorizeRequest{IdempotencyKey: "booking-3", Amount: 500})
+	require.NoError(t, err)
+
+	require.NoError(t, fake.Void(ctx, reference))
+	require.ErrorIs(t, fake.Capture(ctx, reference, 500), ErrInvalidState)
+	require.ErrorIs(t, fake.Void(ctx, "fake_missing"), ErrUnknownPayment)
+}
+
+func TestNewProvider(t *testing.T) {
+	provider, err := NewProvider("")
+	require.NoError(t, err)
+	require.Equal(t, FakeProviderName, provider.Name())
+
+	_, err = NewProvider("acme")
+	require.Error(t, err)
+}
@@ -0,0 +1,44 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrDeclined       = errors.New("payment declined")
+	ErrUnknownPayment = errors.New("unknown payment")
+	ErrInvalidState   = errors.New("payment is not in a state that allows this operation")
+	ErrInvalidAmount  = errors.New("invalid payment amount")
+)
+
+// Provider is a payment gateway. Money is first authorized (held on the guest's card), then either
+// captured or voided; captured money can be refunded in full or in part.
+type Provider interface {
+	// Name identifies the provider and is stored with each payment.
+	Name() string
+	// Authorize holds amount and returns the provider's reference for the payment. Calling it again
+	// with the same idempotency key returns the same reference instead of holding the money twice.
+	Authorize(ctx context.Context, req AuthorizeRequest) (string, error)
+	Capture(ctx context.Context, reference string, amount int64) error
+	Refund(ctx context.Context, reference string, amount int64) error
+	Void(ctx context.Context, reference string) error
+}
+
+type AuthorizeRequest struct {
+	IdempotencyKey string
+	Amount         int64
+	// PaymentMethod is the token the client obtained from the provider for the guest's card.
+	PaymentMethod string
+}
+
+// NewProvider returns the provider configured by name. An empty name selects the fake provider.
+func NewProvider(name string) (Provider, error) {
+	switch name {
+	case "", FakeProviderName:
+		return NewFake(), nil
+	default:
+		return nil, fmt.Errorf("unknown payment provider %q", name)
+	}
+}
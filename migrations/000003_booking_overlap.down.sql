@@ -0,0 +1,5 @@
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_no_overlap;
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_dates_check;
+
+INSERT INTO bookings SELECT * FROM bookings_overlapping ON CONFLICT (id) DO NOTHING;
+DROP TABLE IF EXISTS bookings_overlapping;
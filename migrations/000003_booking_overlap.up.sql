@@ -0,0 +1,23 @@
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+-- Older clients could save the dates the wrong way round or as an empty stay.
+UPDATE bookings SET check_in = check_out, check_out = check_in WHERE check_out < check_in;
+UPDATE bookings SET check_out = check_in + 1 WHERE check_out = check_in;
+
+-- Of two bookings for the same nights only the first could ever be honoured. The later ones are
+-- moved aside, where the down migration can restore them from.
+CREATE TABLE IF NOT EXISTS bookings_overlapping (LIKE bookings);
+
+WITH overlapping AS (
+    DELETE FROM bookings b
+    USING bookings earlier
+    WHERE earlier.listing_id = b.listing_id AND earlier.id < b.id
+      AND daterange(earlier.check_in, earlier.check_out, '[)') && daterange(b.check_in, b.check_out, '[)')
+    RETURNING b.*
+)
+INSERT INTO bookings_overlapping SELECT * FROM overlapping;
+
+ALTER TABLE bookings ADD CONSTRAINT bookings_dates_check CHECK (check_out > check_in);
+
+ALTER TABLE bookings ADD CONSTRAINT bookings_no_overlap
+    EXCLUDE USING gist (listing_id WITH =, daterange(check_in, check_out, '[)') WITH &&);
@@ -0,0 +1,16 @@
+DROP INDEX IF EXISTS bookings_status_idx;
+
+DELETE FROM bookings WHERE status NOT IN ('pending', 'confirmed', 'completed');
+
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_no_overlap;
+ALTER TABLE bookings ADD CONSTRAINT bookings_no_overlap
+    EXCLUDE USING gist (listing_id WITH =, daterange(check_in, check_out, '[)') WITH &&);
+
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_status_check;
+
+ALTER TABLE bookings
+    DROP COLUMN IF EXISTS status,
+    DROP COLUMN IF EXISTS confirmed_at,
+    DROP COLUMN IF EXISTS declined_at,
+    DROP COLUMN IF EXISTS cancelled_at,
+    DROP COLUMN IF EXISTS completed_at;
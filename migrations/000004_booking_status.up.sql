@@ -0,0 +1,20 @@
+ALTER TABLE bookings
+    ADD COLUMN IF NOT EXISTS status text NOT NULL DEFAULT 'pending',
+    ADD COLUMN IF NOT EXISTS confirmed_at timestamp(0),
+    ADD COLUMN IF NOT EXISTS declined_at timestamp(0),
+    ADD COLUMN IF NOT EXISTS cancelled_at timestamp(0),
+    ADD COLUMN IF NOT EXISTS completed_at timestamp(0);
+
+-- Every booking made before this migration was accepted on insert.
+UPDATE bookings SET status = 'confirmed', confirmed_at = created_at;
+
+ALTER TABLE bookings ADD CONSTRAINT bookings_status_check
+    CHECK (status IN ('pending', 'confirmed', 'declined', 'cancelled', 'completed'));
+
+-- Declined and cancelled bookings keep their rows but must free the dates.
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_no_overlap;
+ALTER TABLE bookings ADD CONSTRAINT bookings_no_overlap
+    EXCLUDE USING gist (listing_id WITH =, daterange(check_in, check_out, '[)') WITH &&)
+    WHERE (status IN ('pending', 'confirmed'));
+
+CREATE INDEX IF NOT EXISTS bookings_status_idx ON bookings (status);
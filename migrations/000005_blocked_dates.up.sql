@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS blocked_dates (
+    id bigserial PRIMARY KEY,
+    created_at timestamp(0) NOT NULL DEFAULT NOW(),
+    listing_id bigint NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+    start_date date NOT NULL,
+    end_date date NOT NULL,
+    reason text NOT NULL DEFAULT '',
+    CONSTRAINT blocked_dates_range_check CHECK (end_date > start_date)
+);
+
+CREATE INDEX IF NOT EXISTS blocked_dates_listing_range_idx
+    ON blocked_dates USING gist (listing_id, daterange(start_date, end_date, '[)'));
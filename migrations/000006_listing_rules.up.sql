@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS listing_rules (
+    listing_id bigint PRIMARY KEY REFERENCES listings(id) ON DELETE CASCADE,
+    updated_at timestamp(0) NOT NULL DEFAULT NOW(),
+    min_nights integer NOT NULL DEFAULT 1,
+    max_nights integer NOT NULL DEFAULT 365,
+    check_in_weekdays integer[] NOT NULL DEFAULT '{0,1,2,3,4,5,6}',
+    advance_notice_days integer NOT NULL DEFAULT 0
+);
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS pricing_rules (
+    id bigserial PRIMARY KEY,
+    created_at timestamp(0) NOT NULL DEFAULT NOW(),
+    listing_id bigint NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+    kind text NOT NULL,
+    start_date date,
+    end_date date,
+    weekdays integer[] NOT NULL DEFAULT '{}',
+    price integer NOT NULL DEFAULT 0,
+    percent integer NOT NULL DEFAULT 0,
+    min_nights integer NOT NULL DEFAULT 0,
+    CONSTRAINT pricing_rules_kind_check CHECK (kind IN ('season', 'weekday', 'weekend', 'length_of_stay')),
+    CONSTRAINT pricing_rules_range_check CHECK (end_date IS NULL OR end_date > start_date)
+);
+
+CREATE INDEX IF NOT EXISTS pricing_rules_listing_idx ON pricing_rules (listing_id);
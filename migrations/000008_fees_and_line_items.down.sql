@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS booking_line_items;
+DROP FUNCTION IF EXISTS booking_line_items_immutable();
+DROP TABLE IF EXISTS tax_rules;
+
+ALTER TABLE listings
+    DROP COLUMN IF EXISTS cleaning_fee,
+    DROP COLUMN IF EXISTS service_fee_percent;
@@ -0,0 +1,41 @@
+ALTER TABLE listings
+    ADD COLUMN IF NOT EXISTS cleaning_fee integer NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS service_fee_percent integer NOT NULL DEFAULT 0,
+    ADD CONSTRAINT listings_fees_check CHECK (cleaning_fee >= 0 AND service_fee_percent BETWEEN 0 AND 100);
+
+-- An empty location_region applies the tax to every region of the country.
+CREATE TABLE IF NOT EXISTS tax_rules (
+    id bigserial PRIMARY KEY,
+    created_at timestamp(0) NOT NULL DEFAULT NOW(),
+    location_flag text NOT NULL,
+    location_region text NOT NULL DEFAULT '',
+    name text NOT NULL,
+    rate_basis_points integer NOT NULL DEFAULT 0,
+    per_guest_per_night integer NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS tax_rules_location_idx ON tax_rules (location_flag, location_region);
+
+CREATE TABLE IF NOT EXISTS booking_line_items (
+    id bigserial PRIMARY KEY,
+    booking_id bigint NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    position integer NOT NULL,
+    kind text NOT NULL,
+    description text NOT NULL,
+    quantity integer NOT NULL,
+    unit_amount integer NOT NULL,
+    amount integer NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS booking_line_items_booking_idx ON booking_line_items (booking_id, position);
+
+-- Line items record what the guest agreed to pay, so they are never rewritten.
+CREATE OR REPLACE FUNCTION booking_line_items_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'booking line items are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER booking_line_items_no_update
+    BEFORE UPDATE ON booking_line_items
+    FOR EACH ROW EXECUTE FUNCTION booking_line_items_immutable();
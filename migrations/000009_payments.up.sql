@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS payments (
+    id bigserial PRIMARY KEY,
+    created_at timestamp(0) NOT NULL DEFAULT NOW(),
+    updated_at timestamp(0) NOT NULL DEFAULT NOW(),
+    booking_id bigint NOT NULL UNIQUE REFERENCES bookings(id) ON DELETE CASCADE,
+    provider text NOT NULL,
+    reference text NOT NULL,
+    status text NOT NULL,
+    amount integer NOT NULL,
+    captured_amount integer NOT NULL DEFAULT 0,
+    refunded_amount integer NOT NULL DEFAULT 0,
+    CONSTRAINT payments_status_check CHECK (status IN ('authorized', 'captured', 'refunded', 'voided')),
+    CONSTRAINT payments_amounts_check CHECK (captured_amount <= amount AND refunded_amount <= captured_amount)
+);
@@ -0,0 +1,9 @@
+ALTER TABLE bookings
+    DROP CONSTRAINT IF EXISTS bookings_cancellation_tiers_check,
+    DROP COLUMN IF EXISTS cancellation_tier_refund_percents,
+    DROP COLUMN IF EXISTS cancellation_tier_days,
+    DROP COLUMN IF EXISTS cancellation_policy;
+
+ALTER TABLE bookings DROP COLUMN IF EXISTS refund_amount;
+
+DROP TABLE IF EXISTS cancellation_policies;
@@ -0,0 +1,21 @@
+-- Tiers are only stored for custom policies; tier_days[i] pairs with tier_refund_percents[i].
+CREATE TABLE IF NOT EXISTS cancellation_policies (
+    listing_id bigint PRIMARY KEY REFERENCES listings(id) ON DELETE CASCADE,
+    updated_at timestamp(0) NOT NULL DEFAULT NOW(),
+    name text NOT NULL DEFAULT 'flexible',
+    tier_days integer[] NOT NULL DEFAULT '{}',
+    tier_refund_percents integer[] NOT NULL DEFAULT '{}',
+    CONSTRAINT cancellation_policies_name_check CHECK (name IN ('flexible', 'moderate', 'strict', 'custom')),
+    CONSTRAINT cancellation_policies_tiers_check CHECK (cardinality(tier_days) = cardinality(tier_refund_percents))
+);
+
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS refund_amount integer NOT NULL DEFAULT 0;
+
+-- Each booking keeps a copy of the policy it was made under. Bookings from before policies existed
+-- were all made under the flexible one.
+ALTER TABLE bookings
+    ADD COLUMN IF NOT EXISTS cancellation_policy text NOT NULL DEFAULT 'flexible',
+    ADD COLUMN IF NOT EXISTS cancellation_tier_days integer[] NOT NULL DEFAULT '{1}',
+    ADD COLUMN IF NOT EXISTS cancellation_tier_refund_percents integer[] NOT NULL DEFAULT '{100}',
+    ADD CONSTRAINT bookings_cancellation_tiers_check
+        CHECK (cardinality(cancellation_tier_days) = cardinality(cancellation_tier_refund_percents));
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS listing_hosts;
+
+ALTER TABLE users DROP COLUMN IF EXISTS is_admin;
@@ -0,0 +1,9 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_admin boolean NOT NULL DEFAULT false;
+
+-- Co-hosts help the owner run a listing and can see and respond to its bookings.
+CREATE TABLE IF NOT EXISTS listing_hosts (
+    listing_id bigint NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+    user_id bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at timestamp(0) NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (listing_id, user_id)
+);
@@ -0,0 +1,5 @@
+ALTER TABLE booking_line_items DROP COLUMN IF EXISTS change_id;
+
+DROP TABLE IF EXISTS booking_changes;
+
+ALTER TABLE bookings DROP COLUMN IF EXISTS guests;
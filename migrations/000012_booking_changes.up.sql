@@ -0,0 +1,33 @@
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS guests integer NOT NULL DEFAULT 1;
+
+CREATE TABLE IF NOT EXISTS booking_changes (
+    id bigserial PRIMARY KEY,
+    created_at timestamp(0) NOT NULL DEFAULT NOW(),
+    booking_id bigint NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    requested_by bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status text NOT NULL DEFAULT 'pending',
+    from_check_in date NOT NULL,
+    from_check_out date NOT NULL,
+    from_guests integer NOT NULL,
+    from_total integer NOT NULL,
+    to_check_in date NOT NULL,
+    to_check_out date NOT NULL,
+    to_guests integer NOT NULL,
+    to_price integer NOT NULL,
+    to_total integer NOT NULL,
+    -- The card token to charge a change that costs more with, kept until the host decides.
+    payment_method text NOT NULL DEFAULT '',
+    decided_at timestamp(0),
+    CONSTRAINT booking_changes_status_check CHECK (status IN ('pending', 'applied', 'declined')),
+    CONSTRAINT booking_changes_dates_check CHECK (to_check_out > to_check_in)
+);
+
+CREATE INDEX IF NOT EXISTS booking_changes_booking_idx ON booking_changes (booking_id);
+
+-- A booking can only have one change waiting for the host at a time.
+CREATE UNIQUE INDEX IF NOT EXISTS booking_changes_one_pending_idx ON booking_changes (booking_id)
+    WHERE status = 'pending';
+
+-- Line items are never rewritten, so each change brings its own set; the latest applied one is current.
+ALTER TABLE booking_line_items
+    ADD COLUMN IF NOT EXISTS change_id bigint REFERENCES booking_changes(id) ON DELETE CASCADE;
@@ -0,0 +1,15 @@
+DROP INDEX IF EXISTS bookings_pending_expiry_idx;
+
+UPDATE bookings SET status = 'declined', declined_at = expired_at WHERE status = 'expired';
+
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_status_check;
+ALTER TABLE bookings ADD CONSTRAINT bookings_status_check
+    CHECK (status IN ('pending', 'confirmed', 'declined', 'cancelled', 'completed'));
+
+ALTER TABLE bookings
+    DROP COLUMN IF EXISTS expired_at,
+    DROP COLUMN IF EXISTS expires_at;
+
+ALTER TABLE listings DROP COLUMN IF EXISTS request_expiry_hours;
+
+ALTER TABLE listings DROP COLUMN IF EXISTS instant_book;
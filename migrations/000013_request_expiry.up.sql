@@ -0,0 +1,14 @@
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS instant_book boolean NOT NULL DEFAULT false;
+
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS request_expiry_hours integer NOT NULL DEFAULT 24,
+    ADD CONSTRAINT listings_request_expiry_check CHECK (request_expiry_hours BETWEEN 1 AND 168);
+
+ALTER TABLE bookings
+    ADD COLUMN IF NOT EXISTS expires_at timestamp(0),
+    ADD COLUMN IF NOT EXISTS expired_at timestamp(0);
+
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_status_check;
+ALTER TABLE bookings ADD CONSTRAINT bookings_status_check
+    CHECK (status IN ('pending', 'confirmed', 'declined', 'cancelled', 'completed', 'expired'));
+
+CREATE INDEX IF NOT EXISTS bookings_pending_expiry_idx ON bookings (expires_at) WHERE status = 'pending';
@@ -0,0 +1,17 @@
+-- Holds keep dates aside for a few minutes while a guest checks out. Expiry can't be part of an
+-- exclusion constraint, so overlaps are checked under a lock on the listing row instead.
+CREATE TABLE IF NOT EXISTS booking_holds (
+    id bigserial PRIMARY KEY,
+    created_at timestamp(0) NOT NULL DEFAULT NOW(),
+    listing_id bigint NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+    guest_id bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    check_in date NOT NULL,
+    check_out date NOT NULL,
+    expires_at timestamp(0) NOT NULL,
+    CONSTRAINT booking_holds_dates_check CHECK (check_out > check_in)
+);
+
+CREATE INDEX IF NOT EXISTS booking_holds_listing_dates_idx
+    ON booking_holds USING gist (listing_id, daterange(check_in, check_out, '[)'));
+
+CREATE INDEX IF NOT EXISTS booking_holds_expires_at_idx ON booking_holds (expires_at);
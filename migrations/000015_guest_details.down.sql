@@ -0,0 +1,15 @@
+ALTER TABLE bookings
+    DROP CONSTRAINT IF EXISTS bookings_guests_check,
+    DROP CONSTRAINT IF EXISTS bookings_party_check,
+    DROP COLUMN IF EXISTS pets,
+    DROP COLUMN IF EXISTS infants,
+    DROP COLUMN IF EXISTS children,
+    DROP COLUMN IF EXISTS adults;
+
+ALTER TABLE listings
+    DROP CONSTRAINT IF EXISTS listings_extra_guest_fee_check,
+    DROP CONSTRAINT IF EXISTS listings_base_occupancy_check,
+    DROP CONSTRAINT IF EXISTS listings_max_pets_check,
+    DROP COLUMN IF EXISTS extra_guest_fee,
+    DROP COLUMN IF EXISTS base_occupancy,
+    DROP COLUMN IF EXISTS max_pets;
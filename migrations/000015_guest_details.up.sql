@@ -0,0 +1,23 @@
+ALTER TABLE listings
+    ADD COLUMN IF NOT EXISTS max_pets integer NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS base_occupancy integer,
+    ADD COLUMN IF NOT EXISTS extra_guest_fee integer NOT NULL DEFAULT 0;
+
+UPDATE listings SET base_occupancy = guests WHERE base_occupancy IS NULL;
+
+ALTER TABLE listings ALTER COLUMN base_occupancy SET NOT NULL,
+    ADD CONSTRAINT listings_max_pets_check CHECK (max_pets BETWEEN 0 AND 10),
+    ADD CONSTRAINT listings_base_occupancy_check CHECK (base_occupancy BETWEEN 1 AND guests),
+    ADD CONSTRAINT listings_extra_guest_fee_check CHECK (extra_guest_fee >= 0);
+
+ALTER TABLE bookings
+    ADD COLUMN IF NOT EXISTS adults integer NOT NULL DEFAULT 1,
+    ADD COLUMN IF NOT EXISTS children integer NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS infants integer NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS pets integer NOT NULL DEFAULT 0;
+
+UPDATE bookings SET adults = guests;
+
+ALTER TABLE bookings
+    ADD CONSTRAINT bookings_party_check CHECK (adults >= 1 AND children >= 0 AND infants >= 0 AND pets >= 0),
+    ADD CONSTRAINT bookings_guests_check CHECK (guests = adults + children);
@@ -0,0 +1,6 @@
+-- Only the hash of a listing's calendar feed token is stored; rotating the token replaces the row.
+CREATE TABLE IF NOT EXISTS listing_calendar_feeds (
+    listing_id bigint PRIMARY KEY REFERENCES listings(id) ON DELETE CASCADE,
+    token_hash bytea NOT NULL,
+    created_at timestamp(0) NOT NULL DEFAULT NOW()
+);
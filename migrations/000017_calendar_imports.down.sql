@@ -0,0 +1,8 @@
+DELETE FROM blocked_dates WHERE import_id IS NOT NULL;
+
+ALTER TABLE blocked_dates
+    DROP CONSTRAINT IF EXISTS blocked_dates_import_uid_key,
+    DROP COLUMN IF EXISTS external_uid,
+    DROP COLUMN IF EXISTS import_id;
+
+DROP TABLE IF EXISTS calendar_imports;
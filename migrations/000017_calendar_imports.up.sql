@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS calendar_imports (
+    id bigserial PRIMARY KEY,
+    created_at timestamp(0) NOT NULL DEFAULT NOW(),
+    listing_id bigint NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+    name text NOT NULL DEFAULT '',
+    url text NOT NULL,
+    last_attempted_at timestamp(0),
+    last_synced_at timestamp(0),
+    last_error text NOT NULL DEFAULT '',
+    event_count integer NOT NULL DEFAULT 0,
+    CONSTRAINT calendar_imports_listing_url_key UNIQUE (listing_id, url)
+);
+
+CREATE INDEX IF NOT EXISTS calendar_imports_last_attempted_at_idx
+    ON calendar_imports (last_attempted_at NULLS FIRST);
+
+-- Imported events become blocked dates that belong to their feed, keyed by the event's UID, so a
+-- sync can update them in place and remove the ones that disappeared upstream.
+ALTER TABLE blocked_dates
+    ADD COLUMN IF NOT EXISTS import_id bigint REFERENCES calendar_imports(id) ON DELETE CASCADE,
+    ADD COLUMN IF NOT EXISTS external_uid text;
+
+ALTER TABLE blocked_dates ADD CONSTRAINT blocked_dates_import_uid_key UNIQUE (import_id, external_uid);
@@ -0,0 +1,5 @@
+ALTER TABLE listings
+    DROP CONSTRAINT IF EXISTS listings_location_lng_check,
+    DROP CONSTRAINT IF EXISTS listings_location_lat_check;
+
+DROP INDEX IF EXISTS listings_location_idx;
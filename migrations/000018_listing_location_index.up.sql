@@ -0,0 +1,7 @@
+-- Geo searches filter on a box around the point of interest, which this index answers with the
+-- built-in <@ operator; the exact distance is then only computed for the listings inside it.
+CREATE INDEX IF NOT EXISTS listings_location_idx ON listings USING gist (point(location_lng, location_lat));
+
+ALTER TABLE listings
+    ADD CONSTRAINT listings_location_lat_check CHECK (location_lat BETWEEN -90 AND 90),
+    ADD CONSTRAINT listings_location_lng_check CHECK (location_lng BETWEEN -180 AND 180);
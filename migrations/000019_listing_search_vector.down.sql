@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS listings_search_vector_idx;
+
+ALTER TABLE listings DROP COLUMN IF EXISTS search_vector;
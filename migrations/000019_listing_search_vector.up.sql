@@ -0,0 +1,10 @@
+-- Listings are searched by title first, then category and location, then description. The column is
+-- generated, so it can never fall out of step with the text it is built from.
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(category, '')), 'B') ||
+    setweight(to_tsvector('english', coalesce(location_label, '') || ' ' || coalesce(location_region, '')), 'B') ||
+    setweight(to_tsvector('english', coalesce(description, '')), 'C')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS listings_search_vector_idx ON listings USING gin (search_vector);
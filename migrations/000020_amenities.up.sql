@@ -0,0 +1,39 @@
+CREATE TABLE IF NOT EXISTS amenities (
+    key text PRIMARY KEY CHECK (key ~ '^[a-z0-9_]{1,64}$'),
+    created_at timestamp(0) NOT NULL DEFAULT NOW(),
+    label text NOT NULL,
+    icon text NOT NULL DEFAULT '',
+    group_name text NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS listing_amenities (
+    listing_id bigint NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+    amenity_key text NOT NULL REFERENCES amenities(key) ON DELETE CASCADE,
+    PRIMARY KEY (listing_id, amenity_key)
+);
+
+-- Searches look listings up by amenity.
+CREATE INDEX IF NOT EXISTS listing_amenities_amenity_idx ON listing_amenities (amenity_key, listing_id);
+
+INSERT INTO amenities (key, label, icon, group_name) VALUES
+    ('wifi', 'Wifi', 'wifi', 'Essentials'),
+    ('kitchen', 'Kitchen', 'kitchen', 'Essentials'),
+    ('washer', 'Washer', 'washer', 'Essentials'),
+    ('dryer', 'Dryer', 'dryer', 'Essentials'),
+    ('air_conditioning', 'Air conditioning', 'air-conditioning', 'Essentials'),
+    ('heating', 'Heating', 'heating', 'Essentials'),
+    ('workspace', 'Dedicated workspace', 'workspace', 'Essentials'),
+    ('tv', 'TV', 'tv', 'Essentials'),
+    ('pool', 'Pool', 'pool', 'Features'),
+    ('hot_tub', 'Hot tub', 'hot-tub', 'Features'),
+    ('free_parking', 'Free parking on premises', 'parking', 'Features'),
+    ('ev_charger', 'EV charger', 'ev-charger', 'Features'),
+    ('gym', 'Gym', 'gym', 'Features'),
+    ('bbq_grill', 'BBQ grill', 'grill', 'Features'),
+    ('beach_access', 'Beach access', 'beach', 'Location'),
+    ('lake_access', 'Lake access', 'lake', 'Location'),
+    ('ski_in_out', 'Ski-in/ski-out', 'ski', 'Location'),
+    ('smoke_alarm', 'Smoke alarm', 'smoke-alarm', 'Safety'),
+    ('carbon_monoxide_alarm', 'Carbon monoxide alarm', 'co-alarm', 'Safety'),
+    ('first_aid_kit', 'First aid kit', 'first-aid', 'Safety')
+ON CONFLICT (key) DO NOTHING;
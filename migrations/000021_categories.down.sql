@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS listings_category_idx;
+
+ALTER TABLE listings DROP CONSTRAINT IF EXISTS listings_category_fkey;
+
+UPDATE listings l SET category = c.name FROM categories c WHERE c.slug = l.category;
+
+DROP TABLE IF EXISTS categories;
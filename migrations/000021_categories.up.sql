@@ -0,0 +1,76 @@
+CREATE TABLE IF NOT EXISTS categories (
+    slug text PRIMARY KEY CHECK (slug ~ '^[a-z0-9]+(-[a-z0-9]+)*$' AND length(slug) <= 64),
+    created_at timestamp(0) NOT NULL DEFAULT NOW(),
+    name text NOT NULL,
+    icon text NOT NULL DEFAULT '',
+    position integer NOT NULL DEFAULT 0
+);
+
+INSERT INTO categories (slug, name, icon, position) VALUES
+    ('beach', 'Beach', 'beach', 1),
+    ('windmills', 'Windmills', 'windmill', 2),
+    ('modern', 'Modern', 'modern', 3),
+    ('countryside', 'Countryside', 'mountain', 4),
+    ('pools', 'Pools', 'pool', 5),
+    ('islands', 'Islands', 'island', 6),
+    ('lake', 'Lake', 'boat', 7),
+    ('skiing', 'Skiing', 'ski', 8),
+    ('castles', 'Castles', 'castle', 9),
+    ('caves', 'Caves', 'cave', 10),
+    ('camping', 'Camping', 'camping', 11),
+    ('arctic', 'Arctic', 'snowflake', 12),
+    ('desert', 'Desert', 'cactus', 13),
+    ('barns', 'Barns', 'barn', 14),
+    ('lux', 'Lux', 'diamond', 15)
+ON CONFLICT (slug) DO NOTHING;
+
+-- Existing categories are free text. Each one becomes the slug of its lowercased words, so "Beach"
+-- and "beach " end up together. Known legacy spellings of a catalogue entry ("Beachfront", "Pool")
+-- are then mapped onto it; slugs that still aren't in the catalogue are added after it, named after
+-- their most common spelling.
+CREATE FUNCTION pg_temp.category_slug(category text) RETURNS text AS $$
+    SELECT COALESCE(synonyms.slug, normalized.slug)
+    FROM (
+        SELECT COALESCE(NULLIF(trim(BOTH '-' FROM left(regexp_replace(lower(category), '[^a-z0-9]+', '-', 'g'), 64)), ''), 'other') AS slug
+    ) normalized
+    LEFT JOIN (VALUES
+        ('beachfront', 'beach'),
+        ('beaches', 'beach'),
+        ('windmill', 'windmills'),
+        ('modern-home', 'modern'),
+        ('country', 'countryside'),
+        ('pool', 'pools'),
+        ('amazing-pools', 'pools'),
+        ('island', 'islands'),
+        ('lakes', 'lake'),
+        ('lakefront', 'lake'),
+        ('ski', 'skiing'),
+        ('ski-in-ski-out', 'skiing'),
+        ('castle', 'castles'),
+        ('cave', 'caves'),
+        ('campsite', 'camping'),
+        ('campsites', 'camping'),
+        ('deserts', 'desert'),
+        ('barn', 'barns'),
+        ('luxe', 'lux'),
+        ('luxury', 'lux')
+    ) AS synonyms (spelling, slug) ON synonyms.spelling = normalized.slug
+$$ LANGUAGE sql IMMUTABLE;
+
+INSERT INTO categories (slug, name, position)
+SELECT DISTINCT ON (slug) slug, trim(category), 1000
+FROM (
+    SELECT pg_temp.category_slug(category) AS slug, category, count(*) AS uses
+    FROM listings
+    GROUP BY category
+) spellings
+ORDER BY slug, uses DESC, category
+ON CONFLICT (slug) DO NOTHING;
+
+UPDATE listings SET category = pg_temp.category_slug(category)
+WHERE category IS DISTINCT FROM pg_temp.category_slug(category);
+
+ALTER TABLE listings
+    ADD CONSTRAINT listings_category_fkey FOREIGN KEY (category) REFERENCES categories (slug);
+
+CREATE INDEX IF NOT EXISTS listings_category_idx ON listings (category);
@@ -0,0 +1,14 @@
+DROP INDEX IF EXISTS listings_status_idx;
+
+INSERT INTO categories (slug, name, position) VALUES ('other', 'Other', 1000) ON CONFLICT (slug) DO NOTHING;
+UPDATE listings SET category = 'other' WHERE category IS NULL;
+UPDATE listings SET location_lat = 0, location_lng = 0 WHERE location_lat IS NULL OR location_lng IS NULL;
+
+ALTER TABLE listings
+    DROP CONSTRAINT IF EXISTS listings_status_check,
+    DROP CONSTRAINT IF EXISTS listings_location_latlng_check,
+    ALTER COLUMN category SET NOT NULL,
+    ALTER COLUMN location_lat SET NOT NULL,
+    ALTER COLUMN location_lng SET NOT NULL,
+    DROP COLUMN IF EXISTS published_at,
+    DROP COLUMN IF EXISTS status;
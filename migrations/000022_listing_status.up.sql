@@ -0,0 +1,18 @@
+-- Listings that exist already were public, so they start out published.
+ALTER TABLE listings
+    ADD COLUMN IF NOT EXISTS status text NOT NULL DEFAULT 'published',
+    ADD COLUMN IF NOT EXISTS published_at timestamp(0);
+
+UPDATE listings SET published_at = created_at WHERE published_at IS NULL;
+
+-- Drafts may not have picked a category or been placed on the map yet.
+ALTER TABLE listings
+    ALTER COLUMN status SET DEFAULT 'draft',
+    ALTER COLUMN category DROP NOT NULL,
+    ALTER COLUMN location_lat DROP NOT NULL,
+    ALTER COLUMN location_lng DROP NOT NULL,
+    ADD CONSTRAINT listings_location_latlng_check CHECK ((location_lat IS NULL) = (location_lng IS NULL)),
+    ADD CONSTRAINT listings_status_check
+        CHECK (status IN ('draft', 'pending_review', 'published', 'unlisted', 'archived'));
+
+CREATE INDEX IF NOT EXISTS listings_status_idx ON listings (status);
@@ -0,0 +1 @@
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS version integer NOT NULL DEFAULT 1;